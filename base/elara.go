@@ -2,6 +2,7 @@ package base
 
 import (
 	"fmt"
+	"github.com/ElaraLang/elara/interpreter"
 	"github.com/mholt/archiver"
 	"io"
 	"io/ioutil"
@@ -13,21 +14,55 @@ import (
 	"time"
 )
 
-func ExecuteFull(fileName string, scriptMode bool) {
-	LoadStdLib()
+//ExecuteFull runs a file, passing it the given command line arguments, and returns the code that the process should exit with.
+//If the file defines a main function, it is called after the rest of the file has run.
+//If showTiming is true, how long each stage took is written to stderr afterwards.
+//The standard library can do anything, but the file can only do what permissions allow.
+func ExecuteFull(fileName string, scriptMode bool, showTiming bool, args []string, permissions *interpreter.Permissions) int {
+	global := interpreter.NewGlobal()
+	LoadStdLib(global)
 
 	input := loadFile(fileName)
 	start := time.Now()
-	_, lexTime, parseTime, execTime := Execute(&fileName, string(input), scriptMode)
+	code, lexTime, parseTime, ok := lexAndParse(&fileName, string(input))
+	if !ok {
+		return 1
+	}
+
+	execStart := time.Now()
+	evaluator := interpreter.NewInterpreter(code, interpreter.WithArgs(args), interpreter.WithGlobal(global), interpreter.WithPermissions(permissions))
+	exitCode := runPermitted(evaluator, scriptMode)
+	execTime := time.Since(execStart)
 
 	totalTime := time.Since(start)
 
-	fmt.Println("===========================")
-	fmt.Printf("Lexing took %s\nParsing took %s\nExecution took %s\nExecuted in %s.\n", lexTime, parseTime, execTime, totalTime)
-	fmt.Println("===========================")
+	if showTiming {
+		fmt.Fprintln(os.Stderr, "===========================")
+		fmt.Fprintf(os.Stderr, "Lexing took %s\nParsing took %s\nExecution took %s\nExecuted in %s.\n", lexTime, parseTime, execTime, totalTime)
+		fmt.Fprintln(os.Stderr, "===========================")
+	}
+	return exitCode
+}
+
+//runPermitted runs the file and its main function, stopping with exit code 1 if it is denied a permission
+func runPermitted(evaluator *interpreter.Interpreter, scriptMode bool) (exitCode int) {
+	defer func() {
+		r := recover()
+		switch t := r.(type) {
+		case nil:
+		case *interpreter.PermissionError:
+			fmt.Fprintln(os.Stderr, t.Error())
+			exitCode = 1
+		default:
+			panic(r)
+		}
+	}()
+	evaluator.Exec(scriptMode)
+	return evaluator.RunMain()
 }
 
-func LoadStdLib() {
+//LoadStdLib downloads the standard library if necessary, and runs it so that code using the same Global can import it
+func LoadStdLib(global *interpreter.Global) {
 	usr, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -46,7 +81,9 @@ func LoadStdLib() {
 	}
 	downloadStandardLibrary(filePath)
 
-	filepath.Walk(elaraPath, loadWalkedFile)
+	filepath.Walk(elaraPath, func(path string, info os.FileInfo, err error) error {
+		return loadWalkedFile(global, path, info, err)
+	})
 }
 
 func downloadStandardLibrary(to string) {
@@ -79,7 +116,7 @@ func downloadStandardLibrary(to string) {
 	}
 }
 
-func loadWalkedFile(path string, info os.FileInfo, err error) error {
+func loadWalkedFile(global *interpreter.Global, path string, info os.FileInfo, err error) error {
 	if err != nil {
 		panic(err)
 	}
@@ -90,7 +127,7 @@ func loadWalkedFile(path string, info os.FileInfo, err error) error {
 		return nil
 	}
 	content := loadFile(path)
-	Execute(&path, string(content), false)
+	ExecuteIn(global, &path, string(content), false)
 	return nil
 }
 
@@ -9,7 +9,28 @@ import (
 	"time"
 )
 
+//Execute runs code in a new interpreter, which can't see the namespaces declared by any other code
 func Execute(fileName *string, code string, scriptMode bool) (results []*interpreter.Value, lexTime, parseTime, execTime time.Duration) {
+	return ExecuteIn(interpreter.NewGlobal(), fileName, code, scriptMode)
+}
+
+//ExecuteIn runs code in a new interpreter using the given Global, so that it can import namespaces declared by earlier code using the same Global
+func ExecuteIn(global *interpreter.Global, fileName *string, code string, scriptMode bool) (results []*interpreter.Value, lexTime, parseTime, execTime time.Duration) {
+	parseRes, lexTime, parseTime, ok := lexAndParse(fileName, code)
+	if !ok {
+		return []*interpreter.Value{}, lexTime, parseTime, time.Duration(-1)
+	}
+
+	start := time.Now()
+	evaluator := interpreter.NewInterpreter(parseRes, interpreter.WithGlobal(global))
+
+	results = evaluator.Exec(scriptMode)
+	execTime = time.Since(start)
+	return results, lexTime, parseTime, execTime
+}
+
+//lexAndParse parses the code, printing any syntax errors. ok is false if there were errors
+func lexAndParse(fileName *string, code string) (parseRes []parser.Stmt, lexTime, parseTime time.Duration, ok bool) {
 	start := time.Now()
 	result := lexer.Lex(code)
 	lexTime = time.Since(start)
@@ -28,13 +49,7 @@ func Execute(fileName *string, code string, scriptMode bool) (results []*interpr
 		for _, err := range errs {
 			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err))
 		}
-		return []*interpreter.Value{}, lexTime, parseTime, time.Duration(-1)
+		return nil, lexTime, parseTime, false
 	}
-
-	start = time.Now()
-	evaluator := interpreter.NewInterpreter(parseRes)
-
-	results = evaluator.Exec(scriptMode)
-	execTime = time.Since(start)
-	return results, lexTime, parseTime, execTime
+	return parseRes, lexTime, parseTime, true
 }
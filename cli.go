@@ -2,36 +2,121 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
 	"github.com/urfave/cli/v2"
 	"os"
+	"strings"
 )
 
+//Scripts can't use the network, files or environment variables unless they are allowed to with these flags.
+//Each flag takes a comma separated list, eg --allow-read=data,config.json, or allows everything when it is given on its own, eg --allow-env
+var permissionFlags = []string{"allow-net", "allow-read", "allow-write", "allow-env"}
+
+//expandPermissionFlags gives a value of * to permission flags without one, as the cli library requires every StringSliceFlag to have a value.
+//Only the flags before the file name are changed, so that the script's own arguments are passed through untouched.
+//A flag followed by a separate value, eg --allow-read data script.elr, would allow everything and run data, so it is rejected: values must be given with =
+func expandPermissionFlags(args []string) ([]string, error) {
+	expanded := make([]string, len(args))
+	copy(expanded, args)
+	for i := 1; i < len(expanded); i++ {
+		arg := expanded[i]
+		if arg == "run" {
+			continue
+		}
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		for _, flag := range permissionFlags {
+			if arg != "--"+flag && arg != "-"+flag {
+				continue
+			}
+			if i+2 < len(expanded) && !strings.HasPrefix(expanded[i+1], "-") && expanded[i+2] != "--" {
+				value := expanded[i+1]
+				return nil, fmt.Errorf("%s %s is ambiguous: use %s=%s to allow %s, or put -- before the script's arguments", arg, value, arg, value, value)
+			}
+			expanded[i] = arg + "=" + interpreter.AllowAll
+		}
+	}
+	return expanded, nil
+}
+
+func permissions(c *cli.Context) *interpreter.Permissions {
+	if c.Bool("allow-all") {
+		return interpreter.AllPermissions()
+	}
+	return &interpreter.Permissions{
+		Net:   c.StringSlice("allow-net"),
+		Read:  c.StringSlice("allow-read"),
+		Write: c.StringSlice("allow-write"),
+		Env:   c.StringSlice("allow-env"),
+	}
+}
+
 func main() {
+	scriptFlag := &cli.BoolFlag{
+		Name:  "script",
+		Value: false,
+		Usage: "Script Mode (print the result of every expression)",
+	}
+	timeFlag := &cli.BoolFlag{
+		Name:  "time",
+		Value: false,
+		Usage: "Print how long lexing, parsing and execution took",
+	}
+	flags := []cli.Flag{scriptFlag, timeFlag,
+		&cli.StringSliceFlag{Name: "allow-net", Usage: "Allow network access to the given hosts, or every host"},
+		&cli.StringSliceFlag{Name: "allow-read", Usage: "Allow reading the given files and directories, or every file"},
+		&cli.StringSliceFlag{Name: "allow-write", Usage: "Allow writing to the given files and directories, or every file"},
+		&cli.StringSliceFlag{Name: "allow-env", Usage: "Allow reading the given environment variables, or every variable"},
+		&cli.BoolFlag{Name: "allow-all", Aliases: []string{"A"}, Usage: "Allow everything"},
+	}
+
+	//Everything after the file name is passed to the script, eg elara run script.elr -- a b c
+	run := func(c *cli.Context) error {
+		fileName := c.Args().First()
+		if fileName == "" {
+			return errors.New("no file provided to execute - nothing to do")
+		}
+
+		scriptArgs := c.Args().Tail()
+		if len(scriptArgs) != 0 && scriptArgs[0] == "--" {
+			scriptArgs = scriptArgs[1:]
+		}
+
+		scriptMode := c.Bool("script")
+		exitCode := base.ExecuteFull(fileName, scriptMode, c.Bool("time"), scriptArgs, permissions(c))
+		if exitCode != 0 {
+			return cli.Exit("", exitCode)
+		}
+		return nil
+	}
+
 	app := &cli.App{
-		Name:  "Elara",
-		Usage: "ExecuteFull Elara Code",
-
-		Flags: []cli.Flag{
-			&cli.BoolFlag{
-				Name:  "script",
-				Value: false,
-				Usage: "Script Mode (print the result of every expression)",
-			},
-		},
-		Action: func(c *cli.Context) error {
-			fileName := c.Args().Get(0)
-			if fileName == "" {
-				return errors.New("no file provided to execute - nothing to do")
-			}
+		Name:      "Elara",
+		Usage:     "ExecuteFull Elara Code",
+		ArgsUsage: "file [-- args...]",
 
-			scriptMode := c.Bool("script")
-			base.ExecuteFull(fileName, scriptMode)
-			return nil
+		Flags:  flags,
+		Action: run,
+		Commands: []*cli.Command{
+			{
+				Name:      "run",
+				Usage:     "Run an Elara file",
+				ArgsUsage: "file [-- args...]",
+				Flags:     flags,
+				Action:    run,
+			},
 		},
 	}
 
-	err := app.Run(os.Args)
+	args, err := expandPermissionFlags(os.Args)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	err = app.Run(args)
 	if err != nil {
 		panic(err)
 	}
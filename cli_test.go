@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandPermissionFlags(t *testing.T) {
+	expanded := map[string][]string{
+		"elara --allow-env script.elr":                {"elara", "--allow-env=*", "script.elr"},
+		"elara run --allow-read=data script.elr":      {"elara", "run", "--allow-read=data", "script.elr"},
+		"elara --allow-net script.elr -- --allow-env": {"elara", "--allow-net=*", "script.elr", "--", "--allow-env"},
+	}
+	for command, expected := range expanded {
+		args, err := expandPermissionFlags(strings.Fields(command))
+		if err != nil || !reflect.DeepEqual(args, expected) {
+			t.Errorf("Expected %q to become %q, but got %q, %v", command, expected, args, err)
+		}
+	}
+
+	//data could be the paths to allow, or the script
+	if _, err := expandPermissionFlags(strings.Fields("elara --allow-read data script.elr")); err == nil {
+		t.Error("Expected a permission flag followed by a separate value to be rejected")
+	}
+}
@@ -0,0 +1,237 @@
+//Package elara runs Elara code from Go programs.
+//
+//A Runtime is an isolated Elara environment. Code is evaluated in it with Eval or EvalFile,
+//and the globals that the code defines can be read with Global or called with Call.
+//Go functions can be made available to Elara with Register, and Go types and packages with RegisterType and RegisterPackage.
+//Values are converted between Go and Elara automatically: see interpreter.ToValue and interpreter.FromValue.
+//
+//Runtimes aren't sandboxed unless they are given permissions: see New.
+package elara
+
+import (
+	"context"
+	"fmt"
+	"github.com/ElaraLang/elara/interpreter"
+	"github.com/ElaraLang/elara/lexer"
+	"github.com/ElaraLang/elara/parser"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+type Runtime struct {
+	interpreter *interpreter.Interpreter
+}
+
+//New creates a Runtime. The options are the same as for interpreter.NewInterpreter,
+//eg to set the runtime's stdout, or limit how many steps code can take with interpreter.WithStepLimit.
+//
+//By default the code can read and write any file, read any environment variable and use the network, as the Go program could.
+//Code that isn't trusted must be given interpreter.WithPermissions, eg interpreter.WithPermissions(interpreter.NoPermissions())
+func New(options ...interpreter.Option) *Runtime {
+	return &Runtime{
+		interpreter: interpreter.NewEmptyInterpreter(options...),
+	}
+}
+
+//A SyntaxError is returned when code can't be parsed
+type SyntaxError struct {
+	Errors []parser.ParseError
+}
+
+func (e *SyntaxError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+//A RuntimeError is returned when code fails while it is running
+type RuntimeError struct {
+	Message string
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+//An ExitError is returned when code calls exit. Once a script has exited, the Runtime can't run anything else
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exited with code %d", e.Code)
+}
+
+//recoverError converts a panic into a RuntimeError
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	switch t := r.(type) {
+	case *interpreter.LimitError, *interpreter.PermissionError:
+		*err = t.(error)
+	case error:
+		*err = &RuntimeError{Message: t.Error()}
+	default:
+		*err = &RuntimeError{Message: fmt.Sprint(t)}
+	}
+}
+
+func (r *Runtime) exitError() error {
+	code, exited := r.interpreter.ExitCode()
+	if !exited {
+		return nil
+	}
+	return &ExitError{Code: code}
+}
+
+//Eval runs some code, and returns the value of its last line converted to a Go value.
+//If the last line gives an Elara Error, it is returned as the error, in the same way as Call.
+func (r *Runtime) Eval(code string) (interface{}, error) {
+	return r.EvalContext(context.Background(), code)
+}
+
+//EvalContext runs some code in the same way as Eval, but stops with an *interpreter.LimitError if the context.Context is cancelled
+func (r *Runtime) EvalContext(goContext context.Context, code string) (result interface{}, err error) {
+	defer recoverError(&err)
+	if err := r.exitError(); err != nil {
+		return nil, err
+	}
+	statements, parseErrors := parser.NewParser(lexer.Lex(code)).Parse()
+	if len(parseErrors) != 0 {
+		return nil, &SyntaxError{Errors: parseErrors}
+	}
+	r.interpreter.ResetLines(&statements)
+	values, err := r.interpreter.ExecContext(goContext, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.exitError(); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 || values[len(values)-1] == nil {
+		return nil, nil
+	}
+	return resultOf(values[len(values)-1])
+}
+
+//EvalFile runs the code in a file, in the same way as Eval
+func (r *Runtime) EvalFile(path string) (interface{}, error) {
+	code, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return r.Eval(string(code))
+}
+
+//Global gets the value of a global variable converted to a Go value, and whether the variable exists.
+//A lazy global is evaluated the first time it is read, and the error is set if its initializer fails
+func (r *Runtime) Global(name string) (result interface{}, exists bool, err error) {
+	defer recoverError(&err)
+	value := r.interpreter.Global(name)
+	if value == nil {
+		return nil, false, nil
+	}
+	return interpreter.FromValue(value), true, nil
+}
+
+//Set defines a global variable, converting the Go value to an Elara value
+func (r *Runtime) Set(name string, value interface{}) error {
+	converted, err := interpreter.ToValue(r.interpreter.Context(), value)
+	if err != nil {
+		return err
+	}
+	r.interpreter.Define(name, converted)
+	return nil
+}
+
+//Call calls the global function with the given name, converting the arguments to Elara values and the result to a Go value.
+//If the function returns an Elara Error, it is returned as the error.
+func (r *Runtime) Call(name string, arguments ...interface{}) (interface{}, error) {
+	return r.CallContext(context.Background(), name, arguments...)
+}
+
+//CallContext calls a global function in the same way as Call, but stops if the context.Context is cancelled
+func (r *Runtime) CallContext(goContext context.Context, name string, arguments ...interface{}) (result interface{}, err error) {
+	defer recoverError(&err)
+	value := r.interpreter.Global(name)
+	if value == nil {
+		return nil, fmt.Errorf("no global named %s", name)
+	}
+	function, isFunction := value.Value.(*interpreter.Function)
+	if !isFunction {
+		return nil, fmt.Errorf("%s is a %s, not a function", name, value.Type.Name())
+	}
+	return r.CallFunctionContext(goContext, function, arguments...)
+}
+
+//CallFunction calls an Elara function, such as one returned by Global, in the same way as Call
+func (r *Runtime) CallFunction(function *interpreter.Function, arguments ...interface{}) (interface{}, error) {
+	return r.CallFunctionContext(context.Background(), function, arguments...)
+}
+
+//CallFunctionContext calls an Elara function in the same way as CallFunction, but stops if the context.Context is cancelled
+func (r *Runtime) CallFunctionContext(goContext context.Context, function *interpreter.Function, arguments ...interface{}) (result interface{}, err error) {
+	defer recoverError(&err)
+	if err := r.exitError(); err != nil {
+		return nil, err
+	}
+	values := make([]*interpreter.Value, len(arguments))
+	for i, argument := range arguments {
+		value, err := interpreter.ToValue(r.interpreter.Context(), argument)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	returned, err := r.interpreter.CallContext(goContext, function, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.exitError(); err != nil {
+		return nil, err
+	}
+	return resultOf(returned)
+}
+
+//resultOf converts the value that Elara code gave back to a Go value. An Elara Error is returned as the error, so that Eval and Call fail in the same way
+func resultOf(value *interpreter.Value) (interface{}, error) {
+	result := interpreter.FromValue(value)
+	if elaraError, isError := result.(*interpreter.Error); isError {
+		return nil, elaraError
+	}
+	return result, nil
+}
+
+//Register makes a Go function available to Elara code as a global function.
+//Its parameters and results are converted automatically, and it can return a value, an error, or both.
+func (r *Runtime) Register(name string, function interface{}) error {
+	wrapped, err := interpreter.NewGoFunction(r.interpreter.Context(), name, function)
+	if err != nil {
+		return err
+	}
+	value, err := interpreter.ToValue(r.interpreter.Context(), wrapped)
+	if err != nil {
+		return err
+	}
+	r.interpreter.Define(name, value)
+	return nil
+}
+
+//RegisterType gives a Go type a name, so that Elara code can check for it with is, eg value is Person.
+//Values of the type can be passed to Elara with Set or returned from Go functions without registering it: they are wrapped so that Elara code
+//can read and set their exported fields and call their methods. A struct and a pointer to it are the same type to Elara.
+func (r *Runtime) RegisterType(name string, goType reflect.Type) {
+	r.interpreter.DefineType(name, interpreter.NewGoType(r.interpreter.Context(), goType))
+}
+
+//RegisterPackage makes a set of Go functions, values and types available to Elara code that imports the namespace, eg
+//	runtime.RegisterPackage("go/strings", map[string]interface{}{"ToUpper": strings.ToUpper, "Repeat": strings.Repeat})
+//lets Elara code call ToUpper and Repeat after import go/strings. Members that are reflect.Types are registered as types, as with RegisterType.
+func (r *Runtime) RegisterPackage(namespace string, members map[string]interface{}) error {
+	return r.interpreter.DeclareGoPackage(namespace, members)
+}
@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"strconv"
+)
+
+var ListBuilderType = NewEmptyType("ListBuilder")
+var MapBuilderType = NewEmptyType("MapBuilder")
+
+//A ListBuilder is a mutable list, for building up a collection in a loop without creating a new collection for every element.
+//Once it is built, the builder can still be used, but changes to it don't affect the collections it already built.
+type ListBuilder struct {
+	elements []*Value
+}
+
+func (b *ListBuilder) Size() int {
+	return len(b.elements)
+}
+
+func (b *ListBuilder) String() string {
+	return "ListBuilder(size=" + strconv.Itoa(len(b.elements)) + ")"
+}
+
+//Build creates a collection of every element added so far, typed by the common type of the elements
+func (b *ListBuilder) Build(ctx *Context) *Value {
+	return CollectionValue(commonElementType(ctx, b.elements), b.elements)
+}
+
+//A MapBuilder is a mutable map. Unlike Map's put and remove, changing a MapBuilder doesn't create a new value.
+type MapBuilder struct {
+	current *Map
+}
+
+func (b *MapBuilder) Size() int {
+	return b.current.Size()
+}
+
+func (b *MapBuilder) String() string {
+	return "MapBuilder(size=" + strconv.Itoa(b.current.Size()) + ")"
+}
+
+//Build creates a map of every entry put so far, typed by the common types of the keys and values
+func (b *MapBuilder) Build(ctx *Context) *Value {
+	built := MapOf(ctx, b.current.Entries())
+	return NewValue(built.MapType, built)
+}
+
+func InitBuilders(ctx *Context) {
+	define(ctx, "listBuilder", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{},
+			ReturnType: ListBuilderType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			return NonReturningValue(NewValue(ListBuilderType, &ListBuilder{elements: []*Value{}}))
+		}),
+	})
+
+	defineImpure(ctx, "add", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: ListBuilderType,
+				},
+				{
+					Name:     "value",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: ListBuilderType,
+		},
+		//Returns the builder, so that calls can be chained
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			thisParam := ctx.FindParameter(0)
+			this := thisParam.Value.(*ListBuilder)
+			this.elements = append(this.elements, ctx.FindParameter(1))
+			return NonReturningValue(thisParam)
+		}),
+	})
+
+	defineImpure(ctx, "set", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: ListBuilderType,
+				},
+				{
+					Name:     "index",
+					Type:     IntType,
+					Position: 1,
+				},
+				{
+					Name:     "value",
+					Type:     AnyType,
+					Position: 2,
+				},
+			},
+			ReturnType: ListBuilderType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			thisParam := ctx.FindParameter(0)
+			this := thisParam.Value.(*ListBuilder)
+			index := ctx.FindParameter(1).Value.(int64)
+			if index < 0 || index >= int64(len(this.elements)) {
+				panic("Index " + strconv.FormatInt(index, 10) + " out of bounds for builder of size " + strconv.Itoa(len(this.elements)))
+			}
+			this.elements[index] = ctx.FindParameter(2)
+			return NonReturningValue(thisParam)
+		}),
+	})
+
+	define(ctx, "build", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: ListBuilderType,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*ListBuilder)
+			return NonReturningValue(this.Build(ctx))
+		}),
+	})
+
+	define(ctx, "mapBuilder", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{},
+			ReturnType: MapBuilderType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			return NonReturningValue(NewValue(MapBuilderType, &MapBuilder{current: NewMap(anyMapType)}))
+		}),
+	})
+
+	defineImpure(ctx, "put", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: MapBuilderType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "value",
+					Type:     AnyType,
+					Position: 2,
+				},
+			},
+			ReturnType: MapBuilderType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			thisParam := ctx.FindParameter(0)
+			this := thisParam.Value.(*MapBuilder)
+			this.current = this.current.Put(ctx, ctx.FindParameter(1), ctx.FindParameter(2))
+			return NonReturningValue(thisParam)
+		}),
+	})
+
+	defineImpure(ctx, "remove", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: MapBuilderType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*MapBuilder)
+			var removed *Value
+			this.current, removed = this.current.Remove(ctx, ctx.FindParameter(1))
+			return NonReturningValue(BooleanValue(removed != nil))
+		}),
+	})
+
+	define(ctx, "containsKey", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: MapBuilderType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*MapBuilder)
+			return NonReturningValue(BooleanValue(this.current.ContainsKey(ctx, ctx.FindParameter(1))))
+		}),
+	})
+
+	define(ctx, "build", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: MapBuilderType,
+				},
+			},
+			ReturnType: anyMapType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*MapBuilder)
+			return NonReturningValue(this.Build(ctx))
+		}),
+	})
+}
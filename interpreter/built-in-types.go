@@ -1,8 +1,8 @@
 package interpreter
 
 import (
-	"fmt"
 	"github.com/ElaraLang/elara/util"
+	"strings"
 )
 
 var AnyType = NewEmptyType("Any")
@@ -25,6 +25,12 @@ var types = []Type{
 	StringType,
 	CharType,
 	OutputType,
+	RangeType,
+	ListBuilderType,
+	MapBuilderType,
+	StringBuilderType,
+	ErrorType,
+	RegexType,
 }
 
 func Init(context *Context) {
@@ -34,6 +40,13 @@ func Init(context *Context) {
 	context.types["String"] = StringType
 
 	InitInts(context)
+	InitMaps(context)
+	InitCollections(context)
+	InitParallel(context)
+	InitSets(context)
+	InitBuilders(context)
+	InitStrings(context)
+	InitErrors(context)
 
 	stringPlusName := "plus"
 	stringPlus := &Function{
@@ -54,7 +67,7 @@ func Init(context *Context) {
 		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
 			this := ctx.FindParameter(0)
 			otherParam := ctx.FindParameter(1)
-			concatenated := this.Value.(*Collection).elemsAsString() + util.Stringify(otherParam.Value)
+			concatenated := stringOf(this) + util.Stringify(otherParam.Value)
 			return NonReturningValue(&Value{
 				Type:  StringType,
 				Value: concatenated,
@@ -92,7 +105,7 @@ func Init(context *Context) {
 			this := ctx.FindParameter(0)
 			otherParam := ctx.FindParameter(1)
 
-			concatenated := ctx.Stringify(this) + otherParam.Value.(*Collection).elemsAsString()
+			concatenated := ctx.Stringify(this) + stringOf(otherParam)
 			return NonReturningValue(&Value{
 				Type:  StringType,
 				Value: concatenated,
@@ -144,21 +157,13 @@ func Init(context *Context) {
 			ReturnType: NewCollectionTypeOf(AnyType),
 		},
 		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
-			this := ctx.FindParameter(0).Value.(*Collection)
-			other := ctx.FindParameter(1).Value.(*Collection)
+			this := collectionParameter(ctx, 0)
+			other := collectionParameter(ctx, 1)
 
-			elems := make([]*Value, len(this.Elements)+len(other.Elements))
-			for i, element := range this.Elements {
-				elems[i] = element
-			}
-			for i, element := range other.Elements {
-				elems[i+len(this.Elements)] = element
-			}
-
-			newCol := &Collection{
-				ElementType: this.ElementType,
-				Elements:    elems,
-			}
+			//Only the other collection's elements are copied, the rest of the structure is shared with this collection
+			elementType := CommonType([]Type{this.ElementType, other.ElementType}, ctx)
+			ctx.limits.stepBy(int64(other.Size()))
+			newCol := this.Append(elementType, other.Elements()...)
 			return NonReturningValue(&Value{
 				Type:  NewCollectionType(newCol),
 				Value: newCol,
@@ -194,27 +199,25 @@ func Init(context *Context) {
 		},
 		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
 			thisParam := ctx.FindParameter(0)
-			this := thisParam.Value.(*Collection)
 			amount := ctx.FindParameter(1).Value.(int64)
 			if amount == 1 {
 				return NonReturningValue(thisParam)
 			}
+			if asString, isString := thisParam.Value.(string); isString && amount >= 0 {
+				ctx.limits.stepBy(int64(len(asString)) * amount)
+				return NonReturningValue(StringValue(strings.Repeat(asString, int(amount))))
+			}
+			this := collectionParameter(ctx, 0)
 
-			currentElemLength := len(this.Elements)
-			newSize := int64(currentElemLength) * amount
+			elements := this.Elements()
+			newSize := int64(len(elements)) * amount
+			ctx.limits.stepBy(newSize)
 			newColl := make([]*Value, newSize)
 			for i := int64(0); i < newSize; i++ {
-				index := i % amount
-				if currentElemLength == 1 {
-					index = 0
-				}
-				newColl[i] = this.Elements[index]
+				newColl[i] = elements[i%int64(len(elements))]
 			}
 
-			collection := &Collection{
-				ElementType: this.ElementType,
-				Elements:    newColl,
-			}
+			collection := NewCollection(this.ElementType, newColl)
 			return NonReturningValue(NewValue(NewCollectionType(collection), collection))
 		}),
 	})
@@ -236,13 +239,15 @@ func Init(context *Context) {
 			ReturnType: UnitType,
 		},
 		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Output)
 			value := ctx.FindParameter(1)
 			asString := ctx.Stringify(value)
 
-			fmt.Printf("%s", asString)
+			this.Write(asString)
 			return NonReturningValue(UnitValue())
 		}),
-		name: &outputWriteName,
+		name:   &outputWriteName,
+		impure: true,
 	}
 	outputWriteType := NewFunctionType(stringPlus)
 	context.DefineVariable(&Variable{
@@ -255,6 +260,23 @@ func Init(context *Context) {
 		},
 	})
 
+	//Output is buffered, so flush can be used to make sure that everything written so far is visible
+	defineImpure(context, "flush", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: OutputType,
+				},
+			},
+			ReturnType: UnitType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			ctx.FindParameter(0).Value.(*Output).Flush()
+			return NonReturningValue(UnitValue())
+		}),
+	})
+
 	anyEqualsName := "equals"
 	anyEquals := &Function{
 		Signature: Signature{
@@ -281,6 +303,26 @@ func Init(context *Context) {
 				value = a.Equals(c, other)
 			case *Instance:
 				value = a.Equals(c, other)
+			case *Tuple:
+				value = a.Equals(c, other)
+			case *Map:
+				value = a.Equals(c, other)
+			case *Set:
+				value = a.Equals(c, other)
+			case *Range:
+				value = a.Equals(other)
+			case *Error:
+				value = a.Equals(other)
+			case *GoValue:
+				otherGo, otherIsGo := other.Value.(*GoValue)
+				value = otherIsGo && a.equals(otherGo)
+			case string:
+				switch o := other.Value.(type) {
+				case string:
+					value = a == o
+				case *Collection:
+					value = o.ElementType == CharType && o.elemsAsString() == a
+				}
 			case int64:
 				asI64, isI64 := other.Value.(int64)
 				if isI64 && a == asI64 {
@@ -293,7 +335,7 @@ func Init(context *Context) {
 				}
 			}
 			if value == false {
-				value = this == other
+				value = this == other.Value
 			}
 			return NonReturningValue(BooleanValue(value))
 		}),
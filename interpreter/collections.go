@@ -1,13 +1,91 @@
 package interpreter
 
-import "strings"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
 
-//In a proper implementation these would be persistent. But for now, they will do
+//Collections are persistent vectors, so appending or replacing an element gives a new collection in O(log32 n) time,
+//sharing almost everything with the original, which is left unchanged.
 type Collection struct {
 	ElementType Type
-	Elements    []*Value
+	elements    *vector
 
-	cachedAsString *string
+	cachedAsString atomic.Value //The string of a collection of Chars, once it has been built. Atomic as collections can be shared by parallel functions
+}
+
+func NewCollection(elementType Type, elements []*Value) *Collection {
+	values := make([]interface{}, len(elements))
+	for i, element := range elements {
+		values[i] = element
+	}
+	return &Collection{
+		ElementType: elementType,
+		elements:    emptyVector.appendAll(values),
+	}
+}
+
+func (t *Collection) Size() int {
+	return t.elements.size
+}
+
+func (t *Collection) Get(index int) *Value {
+	if index < 0 || index >= t.elements.size {
+		panic(fmt.Sprintf("Index %d out of bounds for collection of size %d", index, t.elements.size))
+	}
+	return t.elements.get(index).(*Value)
+}
+
+//ForEach calls the consumer with every element in order, until it returns false. Unlike Elements, it doesn't copy the collection
+func (t *Collection) ForEach(consumer func(index int, element *Value) bool) {
+	t.elements.forEach(func(index int, value interface{}) bool {
+		return consumer(index, value.(*Value))
+	})
+}
+
+//Slice returns a collection of the elements from start up to, but not including, end
+func (t *Collection) Slice(start int, end int) *Collection {
+	values := make([]interface{}, 0, end-start)
+	for i := start; i < end; i++ {
+		values = append(values, t.elements.get(i))
+	}
+	return &Collection{
+		ElementType: t.ElementType,
+		elements:    emptyVector.appendAll(values),
+	}
+}
+
+//Elements copies the collection's elements into a slice, so should only be used when a slice is needed
+func (t *Collection) Elements() []*Value {
+	elements := make([]*Value, 0, t.elements.size)
+	t.elements.forEach(func(_ int, value interface{}) bool {
+		elements = append(elements, value.(*Value))
+		return true
+	})
+	return elements
+}
+
+//Append returns a collection with the values added to the end
+func (t *Collection) Append(elementType Type, values ...*Value) *Collection {
+	asInterfaces := make([]interface{}, len(values))
+	for i, value := range values {
+		asInterfaces[i] = value
+	}
+	return &Collection{
+		ElementType: elementType,
+		elements:    t.elements.appendAll(asInterfaces),
+	}
+}
+
+//Set returns a collection with the element at the index replaced
+func (t *Collection) Set(index int, value *Value) *Collection {
+	t.Get(index) //Bounds check
+	return &Collection{
+		ElementType: t.ElementType,
+		elements:    t.elements.set(index, value),
+	}
 }
 
 type CollectionType struct {
@@ -42,44 +120,598 @@ func (t *Collection) String() string {
 	if t.ElementType == CharType {
 		return t.elemsAsString()
 	}
-	elemStrings := make([]string, len(t.Elements))
-	for i, element := range t.Elements {
-		elemStrings[i] = element.String()
-	}
+	elemStrings := make([]string, 0, t.Size())
+	t.ForEach(func(_ int, element *Value) bool {
+		elemStrings = append(elemStrings, element.String())
+		return true
+	})
 	return "[" + strings.Join(elemStrings, ", ") + "]"
 }
 
 func (t *Collection) elemsAsString() string {
-	if t.cachedAsString != nil {
-		return *t.cachedAsString
+	if cached, isCached := t.cachedAsString.Load().(string); isCached {
+		return cached
 	}
 
 	if t.ElementType != CharType {
 		panic("Cannot convert collection to string")
 	}
 	builder := strings.Builder{}
-	for _, elem := range t.Elements {
-		builder.WriteRune(elem.Value.(rune))
-	}
+	t.elements.forEach(func(_ int, elem interface{}) bool {
+		builder.WriteRune(elem.(*Value).Value.(rune))
+		return true
+	})
 
 	asString := builder.String()
-	t.cachedAsString = &asString
+	t.cachedAsString.Store(asString)
 	return asString
 }
 
 func (t *Collection) Equals(ctx *Context, other *Value) bool {
+	otherAsString, otherIsString := other.Value.(string)
+	if otherIsString {
+		return t.ElementType == CharType && t.elemsAsString() == otherAsString
+	}
 	otherAsCol, otherIsCol := other.Value.(*Collection)
 	if !otherIsCol {
 		return false
 	}
-	if len(t.Elements) != len(otherAsCol.Elements) {
+	if t.Size() != otherAsCol.Size() {
 		return false
 	}
-	for i, element := range t.Elements {
-		otherElem := otherAsCol.Elements[i]
-		if !element.Equals(ctx, otherElem) {
-			break
+	equal := true
+	t.elements.forEach(func(i int, element interface{}) bool {
+		equal = element.(*Value).Equals(ctx, otherAsCol.Get(i))
+		return equal
+	})
+	return equal
+}
+
+var anyCollectionType = NewCollectionTypeOf(AnyType)
+
+//functionTypeOf creates the type of a function parameter, eg (Any) => Boolean for a predicate
+func functionTypeOf(returnType Type, parameterTypes ...Type) *FunctionType {
+	parameters := make([]Parameter, len(parameterTypes))
+	for i, parameterType := range parameterTypes {
+		parameters[i] = Parameter{
+			Name:     fmt.Sprintf("<param%d>", i),
+			Type:     parameterType,
+			Position: uint(i),
 		}
 	}
-	return true
+	return NewSignatureFunctionType(Signature{
+		Parameters: parameters,
+		ReturnType: returnType,
+	})
+}
+
+//collectionParameter gets a parameter as a collection. Strings are not stored as collections, so they are converted.
+func collectionParameter(ctx *Context, position uint) *Collection {
+	value := ctx.FindParameter(position)
+	asString, isString := value.Value.(string)
+	if isString {
+		return charsOf(asString)
+	}
+	return value.Value.(*Collection)
+}
+
+func CollectionValue(elementType Type, elements []*Value) *Value {
+	collection := NewCollection(elementType, elements)
+	return NewValue(NewCollectionType(collection), collection)
+}
+
+//resultElementType works out the element type of a collection created by applying a function.
+//The function's declared return type is used if it has one, otherwise the common type of the results is used
+func resultElementType(ctx *Context, function *Function, results []*Value) Type {
+	if function.Signature.ReturnType != AnyType {
+		return function.Signature.ReturnType
+	}
+	return commonElementType(ctx, results)
+}
+
+func commonElementType(ctx *Context, elements []*Value) Type {
+	types := make([]Type, len(elements))
+	for i, element := range elements {
+		types[i] = element.Type
+	}
+	return CommonType(types, ctx)
+}
+
+func booleanResult(function *Function, ctx *Context, element *Value) bool {
+	result, isBool := function.Exec(ctx, []*Value{element}).Value.(bool)
+	if !isBool {
+		panic("Predicate function did not return Boolean")
+	}
+	return result
+}
+
+func InitCollections(ctx *Context) {
+	define(ctx, "map", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "transform",
+					Type:     functionTypeOf(AnyType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			transform := ctx.FindParameter(1).Value.(*Function)
+			results := make([]*Value, this.Size())
+			this.ForEach(func(i int, element *Value) bool {
+				results[i] = transform.Exec(ctx, []*Value{element})
+				return true
+			})
+			return NonReturningValue(CollectionValue(resultElementType(ctx, transform, results), results))
+		}),
+	})
+
+	define(ctx, "filter", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "predicate",
+					Type:     functionTypeOf(BooleanType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			predicate := ctx.FindParameter(1).Value.(*Function)
+			results := make([]*Value, 0)
+			this.ForEach(func(_ int, element *Value) bool {
+				if booleanResult(predicate, ctx, element) {
+					results = append(results, element)
+				}
+				return true
+			})
+			return NonReturningValue(CollectionValue(this.ElementType, results))
+		}),
+	})
+
+	define(ctx, "flatMap", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "transform",
+					Type:     functionTypeOf(anyCollectionType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			transform := ctx.FindParameter(1).Value.(*Function)
+			results := make([]*Value, 0)
+			this.ForEach(func(_ int, element *Value) bool {
+				result := transform.Exec(ctx, []*Value{element})
+				if asString, isString := result.Value.(string); isString {
+					for _, char := range asString {
+						results = append(results, CharValue(char))
+					}
+					return true
+				}
+				asCollection, isCollection := result.Value.(*Collection)
+				if !isCollection {
+					panic("flatMap function did not return a collection, instead was " + result.Type.Name())
+				}
+				asCollection.ForEach(func(_ int, element *Value) bool {
+					results = append(results, element)
+					return true
+				})
+				return true
+			})
+			returnType, returnsCollection := transform.Signature.ReturnType.(*CollectionType)
+			if returnsCollection && returnType.ElementType != AnyType {
+				return NonReturningValue(CollectionValue(returnType.ElementType, results))
+			}
+			return NonReturningValue(CollectionValue(commonElementType(ctx, results), results))
+		}),
+	})
+
+	define(ctx, "fold", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "initial",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "operation",
+					Type:     functionTypeOf(AnyType, AnyType, AnyType),
+					Position: 2,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			accumulator := ctx.FindParameter(1)
+			operation := ctx.FindParameter(2).Value.(*Function)
+			this.ForEach(func(_ int, element *Value) bool {
+				accumulator = operation.Exec(ctx, []*Value{accumulator, element})
+				return true
+			})
+			return NonReturningValue(accumulator)
+		}),
+	})
+
+	define(ctx, "reduce", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "operation",
+					Type:     functionTypeOf(AnyType, AnyType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			operation := ctx.FindParameter(1).Value.(*Function)
+			if this.Size() == 0 {
+				panic("Cannot reduce an empty collection")
+			}
+			accumulator := this.Get(0)
+			this.ForEach(func(i int, element *Value) bool {
+				if i != 0 {
+					accumulator = operation.Exec(ctx, []*Value{accumulator, element})
+				}
+				return true
+			})
+			return NonReturningValue(accumulator)
+		}),
+	})
+
+	define(ctx, "any", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "predicate",
+					Type:     functionTypeOf(BooleanType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			predicate := ctx.FindParameter(1).Value.(*Function)
+			found := false
+			this.ForEach(func(_ int, element *Value) bool {
+				found = booleanResult(predicate, ctx, element)
+				return !found
+			})
+			return NonReturningValue(BooleanValue(found))
+		}),
+	})
+
+	define(ctx, "all", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "predicate",
+					Type:     functionTypeOf(BooleanType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			predicate := ctx.FindParameter(1).Value.(*Function)
+			all := true
+			this.ForEach(func(_ int, element *Value) bool {
+				all = booleanResult(predicate, ctx, element)
+				return all
+			})
+			return NonReturningValue(BooleanValue(all))
+		}),
+	})
+
+	define(ctx, "find", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "predicate",
+					Type:     functionTypeOf(BooleanType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		//Returns Unit if no element matches
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			predicate := ctx.FindParameter(1).Value.(*Function)
+			found := UnitValue()
+			this.ForEach(func(_ int, element *Value) bool {
+				if booleanResult(predicate, ctx, element) {
+					found = element
+					return false
+				}
+				return true
+			})
+			return NonReturningValue(found)
+		}),
+	})
+
+	define(ctx, "sortBy", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "comparator",
+					Type:     functionTypeOf(IntType, AnyType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		//The comparator returns a negative number if a comes before b, a positive number if it comes after, and 0 if they are equal.
+		//Sorting is stable, so equal elements keep their order
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			comparator := ctx.FindParameter(1).Value.(*Function)
+			sorted := this.Elements()
+			sort.SliceStable(sorted, func(i, j int) bool {
+				result, isInt := comparator.Exec(ctx, []*Value{sorted[i], sorted[j]}).Value.(int64)
+				if !isInt {
+					panic("Comparator function did not return Int")
+				}
+				return result < 0
+			})
+			return NonReturningValue(CollectionValue(this.ElementType, sorted))
+		}),
+	})
+
+	define(ctx, "groupBy", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "key",
+					Type:     functionTypeOf(AnyType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyMapType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			keyFunction := ctx.FindParameter(1).Value.(*Function)
+			elements := this.Elements()
+			keys := make([]*Value, len(elements))
+			for i, element := range elements {
+				keys[i] = keyFunction.Exec(ctx, []*Value{element})
+			}
+			groupType := NewCollectionTypeOf(this.ElementType)
+			grouped := NewMap(&MapType{
+				KeyType:   resultElementType(ctx, keyFunction, keys),
+				ValueType: groupType,
+			})
+			for i, key := range keys {
+				group := grouped.Get(ctx, key)
+				if group == nil {
+					group = CollectionValue(this.ElementType, []*Value{})
+				}
+				grouped = grouped.Put(ctx, key, NewValue(groupType, group.Value.(*Collection).Append(this.ElementType, elements[i])))
+			}
+			return NonReturningValue(NewValue(grouped.MapType, grouped))
+		}),
+	})
+
+	define(ctx, "zip", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "other",
+					Type:     anyCollectionType,
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		//The result is as long as the shorter of the 2 collections
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			other := collectionParameter(ctx, 1)
+			length := this.Size()
+			if other.Size() < length {
+				length = other.Size()
+			}
+			pairs := make([]*Value, length)
+			for i := 0; i < length; i++ {
+				pairs[i] = TupleValue(this.Get(i), other.Get(i))
+			}
+			pairType := &TupleType{ElementTypes: []Type{this.ElementType, other.ElementType}}
+			return NonReturningValue(CollectionValue(pairType, pairs))
+		}),
+	})
+
+	define(ctx, "take", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "amount",
+					Type:     IntType,
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			amount := clampAmount(ctx.FindParameter(1).Value.(int64), this.Size())
+			taken := this.Slice(0, int(amount))
+			return NonReturningValue(NewValue(NewCollectionType(taken), taken))
+		}),
+	})
+
+	define(ctx, "drop", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "amount",
+					Type:     IntType,
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			amount := clampAmount(ctx.FindParameter(1).Value.(int64), this.Size())
+			remaining := this.Slice(int(amount), this.Size())
+			return NonReturningValue(NewValue(NewCollectionType(remaining), remaining))
+		}),
+	})
+
+	define(ctx, "distinct", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		//Keeps the first occurrence of each element, using the same hashing and equality as maps
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			seen := NewMap(anyMapType)
+			results := make([]*Value, 0)
+			this.ForEach(func(_ int, element *Value) bool {
+				if !seen.ContainsKey(ctx, element) {
+					seen = seen.Put(ctx, element, UnitValue())
+					results = append(results, element)
+				}
+				return true
+			})
+			return NonReturningValue(CollectionValue(this.ElementType, results))
+		}),
+	})
+
+	define(ctx, "reversed", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			ctx.limits.stepBy(int64(this.Size()))
+			reversed := make([]*Value, this.Size())
+			this.ForEach(func(i int, element *Value) bool {
+				reversed[len(reversed)-1-i] = element
+				return true
+			})
+			return NonReturningValue(CollectionValue(this.ElementType, reversed))
+		}),
+	})
+
+	define(ctx, "joinToString", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "separator",
+					Type:     StringType,
+					Position: 1,
+				},
+			},
+			ReturnType: StringType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			separator := stringParameter(ctx, 1)
+			ctx.limits.stepBy(int64(this.Size()))
+			builder := strings.Builder{}
+			this.ForEach(func(i int, element *Value) bool {
+				if i != 0 {
+					builder.WriteString(separator)
+				}
+				builder.WriteString(ctx.Stringify(element))
+				return true
+			})
+			return NonReturningValue(StringValue(builder.String()))
+		}),
+	})
+}
+
+//clampAmount limits the amount of elements to take or drop to the size of the collection
+func clampAmount(amount int64, size int) int64 {
+	if amount < 0 {
+		panic("Amount must not be negative")
+	}
+	if amount > int64(size) {
+		return int64(size)
+	}
+	return amount
 }
@@ -17,6 +17,8 @@ type Command interface {
 type DefineVarCommand struct {
 	Name        string
 	Mutable     bool
+	Lazy        bool
+	Restricted  bool
 	Type        parser.Type
 	value       Command
 	runtimeType Type
@@ -29,18 +31,23 @@ func (c *DefineVarCommand) getType(ctx *Context) Type {
 		if c.Type == nil {
 			return nil
 		}
-		c.runtimeType = FromASTType(c.Type, ctx)
+		runtimeType := FromASTType(c.Type, ctx)
+		if !ctx.canCache() {
+			return runtimeType
+		}
+		c.runtimeType = runtimeType
 	}
 	return c.runtimeType
 }
 
 func (c *DefineVarCommand) Exec(ctx *Context) *ReturnedValue {
-	if c.hashedName == 0 {
-		c.hashedName = util.Hash(c.Name)
-	}
 	var value *Value
 	foundVar, _ := ctx.FindVariableMaxDepth(c.hashedName, 1)
-	if foundVar != nil {
+	if foundVar != nil && foundVar.AccessibleFrom(ctx.namespace) {
+		if c.Lazy || foundVar.Lazy != nil {
+			//Lazy bindings can't be overloaded, as that would mean evaluating them to compare signatures
+			panic("Variable named " + c.Name + " already exists")
+		}
 		asFunction, isFunction := foundVar.Value.Value.(*Function)
 		if isFunction {
 			value = c.value.Exec(ctx).Unwrap()
@@ -54,6 +61,21 @@ func (c *DefineVarCommand) Exec(ctx *Context) *ReturnedValue {
 			panic("Variable named " + c.Name + " already exists")
 		}
 	}
+	if c.Lazy {
+		variableType := c.getType(ctx)
+		variable := &Variable{
+			Name:       c.Name,
+			Mutable:    c.Mutable,
+			Type:       variableType, //If this is nil, it will be inferred when the value is first evaluated
+			Restricted: c.Restricted,
+			Namespace:  ctx.namespace,
+		}
+		variable.Lazy = NewLazyValue(c.Name, c.value, ctx, variableType)
+		variable.Lazy.variable = variable
+		ctx.DefineVariable(variable)
+		return NilValue()
+	}
+
 	if value == nil {
 		value = c.value.Exec(ctx).Unwrap()
 	}
@@ -71,10 +93,12 @@ func (c *DefineVarCommand) Exec(ctx *Context) *ReturnedValue {
 		variableType = value.Type
 	}
 	variable := &Variable{
-		Name:    c.Name,
-		Mutable: c.Mutable,
-		Type:    variableType,
-		Value:   value,
+		Name:       c.Name,
+		Mutable:    c.Mutable,
+		Type:       variableType,
+		Value:      value,
+		Restricted: c.Restricted,
+		Namespace:  ctx.namespace,
 	}
 
 	ctx.DefineVariable(variable)
@@ -89,17 +113,21 @@ type AssignmentCommand struct {
 }
 
 func (c *AssignmentCommand) Exec(ctx *Context) *ReturnedValue {
-	if c.hashedName == 0 {
-		c.hashedName = util.Hash(c.Name)
-	}
 	variable := ctx.FindVariable(c.hashedName)
 	if variable == nil {
 		panic("No such variable " + c.Name)
 	}
+	if ctx.parallel && !variable.parallel {
+		panic("Cannot reassign " + c.Name + " from a parallel function, as it wasn't defined by the function")
+	}
 
 	if !variable.Mutable {
 		panic("Cannot reassign immutable variable " + c.Name)
 	}
+	if variable.Lazy != nil {
+		variable.Get(ctx) //Evaluate it first so that the type is known
+		variable.Lazy = nil
+	}
 
 	value := c.value.Exec(ctx).Unwrap()
 
@@ -111,6 +139,27 @@ func (c *AssignmentCommand) Exec(ctx *Context) *ReturnedValue {
 	return NonReturningValue(value)
 }
 
+//PropertyAssignmentCommand sets a field of a Go value, eg person.Name = "Ada"
+type PropertyAssignmentCommand struct {
+	receiver Command
+	Name     string
+	value    Command
+}
+
+func (c *PropertyAssignmentCommand) Exec(ctx *Context) *ReturnedValue {
+	ctx.checkNotParallel("set the property " + c.Name) //Go values are shared with every worker
+	receiver := c.receiver.Exec(ctx).Unwrap()
+	goValue, isGo := receiver.Value.(*GoValue)
+	if !isGo {
+		panic("Cannot set property " + c.Name + " of " + receiver.String() + ", as only the fields of Go values can be set")
+	}
+	value := c.value.Exec(ctx).Unwrap()
+	if err := goValue.set(c.Name, value); err != nil {
+		panic(err.Error())
+	}
+	return NonReturningValue(value)
+}
+
 type VariableCommand struct {
 	Variable string
 
@@ -119,10 +168,6 @@ type VariableCommand struct {
 }
 
 func (c *VariableCommand) findVariable(ctx *Context) *Variable {
-	if c.hash == 0 {
-		c.hash = util.Hash(c.Variable)
-	}
-
 	variable := ctx.FindVariable(c.hash)
 	return variable
 }
@@ -149,7 +194,7 @@ func (c *VariableCommand) Exec(ctx *Context) *ReturnedValue {
 	}
 	variable := c.findVariable(ctx)
 	if variable != nil {
-		return NonReturningValue(variable.Value)
+		return NonReturningValue(variable.Get(ctx))
 	}
 
 	constructor := ctx.FindConstructor(c.Variable)
@@ -159,17 +204,31 @@ func (c *VariableCommand) Exec(ctx *Context) *ReturnedValue {
 		}
 		panic("No such variable or parameter or constructor " + c.Variable)
 	}
-	c.cachedVar = constructor
+	if ctx.canCache() {
+		c.cachedVar = constructor
+	}
 	return NonReturningValue(constructor)
 }
 
 type InvocationCommand struct {
 	Invoking Command
+	typeArgs []parser.Type
 	args     []Command
 
 	cachedFun *Function
 }
 
+func (c *InvocationCommand) invoke(ctx *Context, fun *Function, argValues []*Value) *Value {
+	if len(c.typeArgs) == 0 {
+		return fun.Exec(ctx, argValues)
+	}
+	typeArgs := make([]Type, len(c.typeArgs))
+	for i, typeArg := range c.typeArgs {
+		typeArgs[i] = FromASTType(typeArg, ctx)
+	}
+	return fun.ExecWithTypeArguments(ctx, typeArgs, argValues)
+}
+
 func (c *InvocationCommand) findReceiverFunction(ctx *Context, receiver *Value, argValues []*Value, functionName string, nameHash uint64) *Function {
 	receiverType := receiver.Type
 	parameters := []Parameter{{
@@ -208,7 +267,7 @@ func (c *InvocationCommand) Exec(ctx *Context) *ReturnedValue {
 
 	if !usingReceiver {
 		if c.cachedFun != nil {
-			return NonReturningValue(c.cachedFun.Exec(ctx, argValues)) //Avoid unnecessary lookup
+			return NonReturningValue(c.invoke(ctx, c.cachedFun, argValues)) //Avoid unnecessary lookup
 		}
 		val := c.Invoking.Exec(ctx).Unwrap()
 		fun, ok := val.Value.(*Function)
@@ -218,12 +277,12 @@ func (c *InvocationCommand) Exec(ctx *Context) *ReturnedValue {
 		switch t := c.Invoking.(type) {
 		case *VariableCommand:
 			variable := t.findVariable(ctx)
-			if variable != nil && !variable.Mutable {
+			if variable != nil && !variable.Mutable && ctx.canCache() {
 				c.cachedFun = fun
 			}
 		}
 
-		return NonReturningValue(fun.Exec(ctx, argValues))
+		return NonReturningValue(c.invoke(ctx, fun, argValues))
 	}
 
 	//ContextCommand seems to think it's a special case... because it is.
@@ -233,10 +292,21 @@ func (c *InvocationCommand) Exec(ctx *Context) *ReturnedValue {
 
 	receiver = context.receiver.Exec(ctx).Unwrap()
 
+	if goValue, isGo := receiver.Value.(*GoValue); isGo {
+		//Go methods are already bound to their receiver
+		if member := goValue.member(ctx, functionName); member != nil {
+			function, ok := member.Value.(*Function)
+			if !ok {
+				panic("Cannot invoke non-function " + functionName)
+			}
+			return NonReturningValue(c.invoke(ctx, function, argValues))
+		}
+	}
+
 	if c.cachedFun != nil {
 		argValuesAndSelf := []*Value{receiver}
 		argValuesAndSelf = append(argValuesAndSelf, argValues...)
-		return NonReturningValue(c.cachedFun.Exec(ctx, argValuesAndSelf))
+		return NonReturningValue(c.invoke(ctx, c.cachedFun, argValuesAndSelf))
 	}
 
 	structType, isStruct := receiver.Type.(*StructType)
@@ -244,6 +314,7 @@ func (c *InvocationCommand) Exec(ctx *Context) *ReturnedValue {
 	if isStruct {
 		value, ok := structType.GetProperty(functionName)
 		if ok {
+			structType.checkAccess(value, ctx)
 			function, ok := value.DefaultValue.Value.(*Function)
 			if !ok {
 				panic("Cannot invoke non-function " + value.Name)
@@ -255,24 +326,26 @@ func (c *InvocationCommand) Exec(ctx *Context) *ReturnedValue {
 			for i, arg := range c.args {
 				argValues[i] = arg.Exec(ctx).Unwrap()
 			}
-			return NonReturningValue(function.Exec(ctx, argValues))
+			return NonReturningValue(c.invoke(ctx, function, argValues))
 		}
 	}
 
 	extension := ctx.FindExtension(receiver.Type, functionName)
 	if extension != nil {
 		fun := extension.Value.Value.Value.(*Function)
-		c.cachedFun = fun
+		if ctx.canCache() {
+			c.cachedFun = fun
+		}
 		argValuesAndSelf := []*Value{receiver}
 		argValuesAndSelf = append(argValuesAndSelf, argValues...)
-		return NonReturningValue(fun.Exec(ctx, argValuesAndSelf))
+		return NonReturningValue(c.invoke(ctx, fun, argValuesAndSelf))
 	}
 
 	//Look for a receiver
 	receiverFunction := c.findReceiverFunction(ctx, receiver, argValues, functionName, context.hash())
 	argValuesAndSelf := []*Value{receiver}
 	argValuesAndSelf = append(argValuesAndSelf, argValues...)
-	return NonReturningValue(receiverFunction.Exec(ctx, argValuesAndSelf))
+	return NonReturningValue(c.invoke(ctx, receiverFunction, argValuesAndSelf))
 }
 
 type AbstractCommand struct {
@@ -298,24 +371,45 @@ func (c *LiteralCommand) Exec(_ *Context) *ReturnedValue {
 }
 
 type FunctionLiteralCommand struct {
-	name       *string
-	parameters []parser.FunctionArgument
-	returnType parser.Type //Can be nil - infer return type
-	body       Command
+	name           *string
+	typeParameters []parser.GenericContract //Only present for generic functions
+	parameters     []parser.FunctionArgument
+	returnType     parser.Type //Can be nil - infer return type
+	body           Command
 
 	currentContext *Context
 }
 
 func (c *FunctionLiteralCommand) Exec(ctx *Context) *ReturnedValue {
-	if c.currentContext == nil {
+	currentContext := c.currentContext
+	if !ctx.canCache() {
+		//The cached snapshot may belong to another worker, which could still be changing it, so parallel functions always take their own
+		currentContext = ctx.Clone()
+	} else if currentContext == nil {
 		c.currentContext = ctx.Clone()
 		//Function literals take a snapshot of their current context to avoid scoping issues
 		//This one will be cached forever, so we don't need to cleanup
+		currentContext = c.currentContext
+	}
+	typeContext := currentContext
+	var typeParameters []*TypeParameter
+	if len(c.typeParameters) != 0 {
+		typeParameters, typeContext = resolveTypeParameters(c.typeParameters, currentContext)
+		defer typeContext.Cleanup()
 	}
 	params := make([]Parameter, len(c.parameters))
 
 	for i, parameter := range c.parameters {
-		paramType := FromASTType(parameter.Type, c.currentContext)
+		if parameter.Pattern != nil {
+			//The parts are checked again as they are destructured, as a collection's size is only known then
+			params[i] = Parameter{
+				Type:     NewPatternType(parameter.Pattern.(parser.TuplePattern), typeContext),
+				Name:     "<destructured>",
+				Position: uint(i),
+			}
+			continue
+		}
+		paramType := FromASTType(parameter.Type, typeContext)
 		params[i] = Parameter{
 			Type:     paramType,
 			Name:     parameter.Name,
@@ -328,17 +422,18 @@ func (c *FunctionLiteralCommand) Exec(ctx *Context) *ReturnedValue {
 	if astReturnType == nil {
 		returnType = AnyType
 	} else {
-		returnType = FromASTType(c.returnType, c.currentContext)
+		returnType = FromASTType(c.returnType, typeContext)
 	}
 
 	fun := &Function{
 		name: c.name,
 		Signature: Signature{
-			Parameters: params,
-			ReturnType: returnType,
+			TypeParameters: typeParameters,
+			Parameters:     params,
+			ReturnType:     returnType,
 		},
 		Body:    c.body,
-		context: c.currentContext,
+		context: currentContext,
 	}
 
 	functionType := NewFunctionType(fun)
@@ -371,7 +466,7 @@ func (c *BlockCommand) Exec(ctx *Context) *ReturnedValue {
 	for _, lineRef := range c.lines {
 		line := *lineRef
 		val := line.Exec(ctx)
-		if val.IsReturning {
+		if val.Interrupted() {
 			return val
 		}
 		last = val
@@ -385,16 +480,18 @@ type ContextCommand struct {
 	hashedVariable uint64
 }
 
-func (c *ContextCommand) hash() uint64 {
-	if c.hashedVariable == 0 {
-		c.hashedVariable = util.Hash(c.variable)
+func newContextCommand(receiver Command, variable string) *ContextCommand {
+	return &ContextCommand{
+		receiver:       receiver,
+		variable:       variable,
+		hashedVariable: util.Hash(variable),
 	}
+}
+
+func (c *ContextCommand) hash() uint64 {
 	return c.hashedVariable
 }
 func (c *ContextCommand) Exec(ctx *Context) *ReturnedValue {
-	if c.hashedVariable == 0 {
-		c.hashedVariable = util.Hash(c.variable)
-	}
 	receiver := c.receiver.Exec(ctx).Unwrap()
 
 	var value *ReturnedValue
@@ -402,19 +499,52 @@ func (c *ContextCommand) Exec(ctx *Context) *ReturnedValue {
 	case *Collection:
 		switch c.variable {
 		case "size":
-			value = NonReturningValue(IntValue(int64(len(val.Elements))))
+			value = NonReturningValue(IntValue(int64(val.Size())))
+		}
+	case string:
+		if c.variable == "size" {
+			value = NonReturningValue(IntValue(int64(stringLength(val))))
+		}
+	case *StringBuilder:
+		if c.variable == "size" {
+			value = NonReturningValue(IntValue(int64(val.length)))
+		}
+	case *Error:
+		if c.variable == "message" {
+			value = NonReturningValue(StringValue(val.Message))
+		}
+	case *Set:
+		if c.variable == "size" {
+			value = NonReturningValue(IntValue(int64(val.Size())))
+		}
+	case *ListBuilder:
+		if c.variable == "size" {
+			value = NonReturningValue(IntValue(int64(val.Size())))
+		}
+	case *MapBuilder:
+		if c.variable == "size" {
+			value = NonReturningValue(IntValue(int64(val.Size())))
+		}
+	case *Range:
+		switch c.variable {
+		case "start":
+			value = NonReturningValue(IntValue(val.Start))
+		case "end":
+			value = NonReturningValue(IntValue(val.End))
+		case "size":
+			value = NonReturningValue(IntValue(val.Size()))
 		}
 	case *Map:
 		switch c.variable {
+		case "size":
+			value = NonReturningValue(IntValue(int64(val.Size())))
 		case "keys":
-			keySet := make([]*Value, len(val.Elements))
-			for i, element := range val.Elements {
+			entries := val.Entries()
+			keySet := make([]*Value, len(entries))
+			for i, element := range entries {
 				keySet[i] = element.Key
 			}
-			collection := &Collection{
-				ElementType: val.MapType.KeyType,
-				Elements:    keySet,
-			}
+			collection := NewCollection(val.MapType.KeyType, keySet)
 			collectionType := NewCollectionType(collection)
 
 			value = NonReturningValue(&Value{
@@ -422,14 +552,12 @@ func (c *ContextCommand) Exec(ctx *Context) *ReturnedValue {
 				Value: collection,
 			})
 		case "values":
-			valueSet := make([]*Value, len(val.Elements))
-			for i, element := range val.Elements {
+			entries := val.Entries()
+			valueSet := make([]*Value, len(entries))
+			for i, element := range entries {
 				valueSet[i] = element.Value
 			}
-			collection := &Collection{
-				ElementType: val.MapType.ValueType,
-				Elements:    valueSet,
-			}
+			collection := NewCollection(val.MapType.ValueType, valueSet)
 			collectionType := NewCollectionType(collection)
 
 			value = NonReturningValue(&Value{
@@ -439,8 +567,14 @@ func (c *ContextCommand) Exec(ctx *Context) *ReturnedValue {
 		}
 	case *Instance:
 		{
+			property, isProperty := val.Type.GetProperty(c.variable)
+			if isProperty {
+				val.Type.checkAccess(property, ctx)
+			}
 			value = NonReturningValue(val.Values[c.variable])
 		}
+	case *GoValue:
+		value = NonReturningValue(val.member(ctx, c.variable))
 	default:
 		panic("Unsupported receiver " + util.Stringify(receiver))
 	}
@@ -456,6 +590,20 @@ func (c *ContextCommand) Exec(ctx *Context) *ReturnedValue {
 	return NonReturningValue(extension.Value.Value)
 }
 
+type NotEqualsCommand struct {
+	equals Command
+}
+
+func (c *NotEqualsCommand) Exec(ctx *Context) *ReturnedValue {
+	val := c.equals.Exec(ctx).Unwrap()
+
+	asBool, ok := (*val).Value.(bool)
+	if !ok {
+		panic("equals function did not return bool")
+	}
+	return NonReturningValue(BooleanValue(!asBool))
+}
+
 type IfElseCommand struct {
 	condition  Command
 	ifBranch   Command
@@ -526,6 +674,7 @@ type NamespaceCommand struct {
 }
 
 func (c *NamespaceCommand) Exec(ctx *Context) *ReturnedValue {
+	ctx.checkNotParallel("declare a namespace")
 	ctx.Init(c.namespace)
 	return NilValue()
 }
@@ -535,6 +684,7 @@ type ImportCommand struct {
 }
 
 func (c *ImportCommand) Exec(ctx *Context) *ReturnedValue {
+	ctx.checkNotParallel("import a namespace")
 	for _, s := range c.imports {
 		ctx.Import(s)
 	}
@@ -542,11 +692,18 @@ func (c *ImportCommand) Exec(ctx *Context) *ReturnedValue {
 }
 
 type StructDefCommand struct {
-	name   string
-	fields []parser.StructField
+	name           string
+	typeParameters []parser.GenericContract //Only present for generic structs
+	fields         []parser.StructField
 }
 
 func (c *StructDefCommand) Exec(ctx *Context) *ReturnedValue {
+	typeContext := ctx
+	var typeParameters []*TypeParameter
+	if len(c.typeParameters) != 0 {
+		typeParameters, typeContext = resolveTypeParameters(c.typeParameters, ctx)
+		defer typeContext.Cleanup()
+	}
 
 	properties := make([]Property, len(c.fields))
 	propertyPositions := map[string]int{}
@@ -556,7 +713,7 @@ func (c *StructDefCommand) Exec(ctx *Context) *ReturnedValue {
 		if field.FieldType == nil {
 			Type = AnyType
 		} else {
-			Type = FromASTType(*field.FieldType, ctx)
+			Type = FromASTType(*field.FieldType, typeContext)
 		}
 
 		var defaultValue *Value
@@ -568,6 +725,9 @@ func (c *StructDefCommand) Exec(ctx *Context) *ReturnedValue {
 		if field.Mutable {
 			modifiers |= Mut
 		}
+		if field.Restricted {
+			modifiers |= Restricted
+		}
 		properties[i] = Property{
 			Name:         field.Identifier,
 			Modifiers:    modifiers,
@@ -581,6 +741,8 @@ func (c *StructDefCommand) Exec(ctx *Context) *ReturnedValue {
 		TypeName:          c.name,
 		Properties:        properties,
 		propertyPositions: propertyPositions,
+		namespace:         ctx.namespace,
+		TypeParameters:    typeParameters,
 	}
 
 	return NilValue()
@@ -593,6 +755,7 @@ type ExtendCommand struct {
 }
 
 func (c *ExtendCommand) Exec(ctx *Context) *ReturnedValue {
+	ctx.checkNotParallel("extend a type")
 	extending := ctx.FindType(c.Type)
 	if extending == nil {
 		panic("No such type " + c.Type)
@@ -618,7 +781,7 @@ func (c *ExtendCommand) Exec(ctx *Context) *ReturnedValue {
 				for i := range signature.Parameters {
 					p := signature.Parameters[i]
 					p.Position++
-					params[i] = p
+					params[i+1] = p
 				}
 				signature.Parameters = params
 				asFunction.Signature = signature
@@ -658,6 +821,7 @@ func (c *TypeCheckCommand) Exec(ctx *Context) *ReturnedValue {
 }
 
 type WhileCommand struct {
+	label     string
 	condition Command
 	body      Command
 }
@@ -667,12 +831,17 @@ func (c *WhileCommand) Exec(ctx *Context) *ReturnedValue {
 		val := c.condition.Exec(ctx)
 		condition, ok := val.Unwrap().Value.(bool)
 		if !ok {
-			panic("If statements requires boolean condition")
+			panic("While loops require boolean condition")
 		}
 		if !condition {
 			break
 		}
-		c.body.Exec(ctx)
+		scope := enterLoopScope(ctx, "while")
+		result, stop := loopControl(c.label, c.body.Exec(scope))
+		scope.Cleanup()
+		if stop {
+			return result
+		}
 	}
 	return NilValue()
 }
@@ -686,17 +855,8 @@ func (c *CollectionCommand) Exec(ctx *Context) *ReturnedValue {
 	for i, element := range c.Elements {
 		elements[i] = element.Exec(ctx).Unwrap()
 	}
-	//In a proper type system we might try and find a union of all elements, but this is dynamic, and I'm lazy
-	var collType Type
-	if len(elements) == 0 {
-		collType = AnyType
-	} else {
-		collType = elements[0].Type
-	}
-	collection := &Collection{
-		ElementType: collType,
-		Elements:    elements,
-	}
+	collType := commonElementType(ctx, elements)
+	collection := NewCollection(collType, elements)
 	collectionType := NewCollectionType(collection)
 
 	return NonReturningValue(&Value{
@@ -705,6 +865,18 @@ func (c *CollectionCommand) Exec(ctx *Context) *ReturnedValue {
 	})
 }
 
+type TupleCommand struct {
+	Elements []Command
+}
+
+func (c *TupleCommand) Exec(ctx *Context) *ReturnedValue {
+	elements := make([]*Value, len(c.Elements))
+	for i, element := range c.Elements {
+		elements[i] = element.Exec(ctx).Unwrap()
+	}
+	return NonReturningValue(TupleValue(elements...))
+}
+
 type AccessCommand struct {
 	checking Command
 	index    Command
@@ -718,11 +890,28 @@ func (c *AccessCommand) Exec(ctx *Context) *ReturnedValue {
 		if !isInt {
 			panic("Index was not an integer")
 		}
-		return NonReturningValue(accessingType.Elements[index])
+		return NonReturningValue(accessingType.Get(int(index)))
+
+	case string:
+		index, isInt := c.index.Exec(ctx).Unwrap().Value.(int64)
+		if !isInt {
+			panic("Index was not an integer")
+		}
+		return NonReturningValue(CharValue(runeAt(accessingType, int(index))))
 
 	case *Map:
 		index := c.index.Exec(ctx).Unwrap()
 		return NonReturningValue(accessingType.Get(ctx, index))
+
+	case *Tuple:
+		index, isInt := c.index.Exec(ctx).Unwrap().Value.(int64)
+		if !isInt {
+			panic("Index was not an integer")
+		}
+		if index < 0 || index >= int64(len(accessingType.Elements)) {
+			panic(fmt.Sprintf("Index %d out of bounds for tuple of size %d", index, len(accessingType.Elements)))
+		}
+		return NonReturningValue(accessingType.Elements[index])
 	}
 	panic("Indexed access not supported for non-collection type")
 }
@@ -762,7 +951,7 @@ func (c *MapCommand) Exec(ctx *Context) *ReturnedValue {
 		elements = append(elements, entry)
 	}
 
-	mapValue := MapOf(elements)
+	mapValue := MapOf(ctx, elements)
 	mapType := mapValue.MapType
 	value := NewValue(mapType, mapValue)
 	return NonReturningValue(value)
@@ -771,12 +960,24 @@ func (c *MapCommand) Exec(ctx *Context) *ReturnedValue {
 func ToCommand(statement parser.Stmt) Command {
 	switch t := statement.(type) {
 	case parser.VarDefStmt:
+		if t.Pattern != nil {
+			return &DestructuringCommand{
+				pattern:    t.Pattern.(parser.TuplePattern),
+				Mutable:    t.Mutable,
+				Restricted: t.Restricted,
+				Type:       t.Type,
+				value:      ExpressionToCommand(t.Value),
+			}
+		}
 		valueExpr := NamedExpressionToCommand(t.Value, &t.Identifier)
 		return &DefineVarCommand{
-			Name:    t.Identifier,
-			Mutable: t.Mutable,
-			Type:    t.Type,
-			value:   valueExpr,
+			Name:       t.Identifier,
+			Mutable:    t.Mutable,
+			Lazy:       t.Lazy,
+			Restricted: t.Restricted,
+			Type:       t.Type,
+			value:      valueExpr,
+			hashedName: util.Hash(t.Identifier),
 		}
 
 	case parser.ExpressionStmt:
@@ -788,8 +989,9 @@ func ToCommand(statement parser.Stmt) Command {
 			cmd := ToCommand(stmt)
 			commands[i] = &cmd
 			_, isReturn := cmd.(*ReturnCommand)
+			_, isJump := cmd.(*JumpCommand)
 			//Small optimisation, it's not worth transforming anything that won't ever be reached
-			if isReturn {
+			if isReturn || isJump {
 				return &BlockCommand{lines: commands[:i+1]}
 			}
 		}
@@ -847,19 +1049,56 @@ func ToCommand(statement parser.Stmt) Command {
 
 	case parser.WhileStmt:
 		return &WhileCommand{
+			label:     t.Label,
 			condition: ExpressionToCommand(t.Condition),
 			body:      ToCommand(t.Body),
 		}
+	case parser.ForStmt:
+		return &ForCommand{
+			label:      t.Label,
+			pattern:    t.Pattern,
+			collection: ExpressionToCommand(t.Collection),
+			body:       ToCommand(t.Body),
+		}
+	case parser.BreakStmt:
+		return &JumpCommand{
+			jump:  BreakJump,
+			label: t.Label,
+		}
+	case parser.ContinueStmt:
+		return &JumpCommand{
+			jump:  ContinueJump,
+			label: t.Label,
+		}
 	case parser.TypeStmt:
 		return &TypeCommand{
 			name:  t.Identifier,
 			value: t.Contract,
 		}
+	case parser.GenerifiedStmt:
+		return generifiedToCommand(t)
 	}
 
 	panic("Could not handle " + reflect.TypeOf(statement).Name())
 }
 
+//generifiedToCommand attaches the type parameters of a generic declaration to the function or struct that it declares
+func generifiedToCommand(stmt parser.GenerifiedStmt) Command {
+	command := ToCommand(stmt.Statement)
+	switch t := command.(type) {
+	case *DefineVarCommand:
+		function, isFunction := t.value.(*FunctionLiteralCommand)
+		if isFunction {
+			function.typeParameters = stmt.Contracts
+			return t
+		}
+	case *StructDefCommand:
+		t.typeParameters = stmt.Contracts
+		return t
+	}
+	panic("Only functions and structs can be generic")
+}
+
 func ExpressionToCommand(expr parser.Expr) Command {
 	return NamedExpressionToCommand(expr, nil)
 }
@@ -868,7 +1107,7 @@ func NamedExpressionToCommand(expr parser.Expr, name *string) Command {
 
 	switch t := expr.(type) {
 	case parser.VariableExpr:
-		return &VariableCommand{Variable: t.Identifier}
+		return &VariableCommand{Variable: t.Identifier, hash: util.Hash(t.Identifier)}
 
 	case parser.InvocationExpr:
 		fun := ExpressionToCommand(t.Invoker)
@@ -883,6 +1122,7 @@ func NamedExpressionToCommand(expr parser.Expr, name *string) Command {
 
 		return &InvocationCommand{
 			Invoking: fun,
+			typeArgs: t.TypeArgs,
 			args:     args,
 		}
 
@@ -891,6 +1131,13 @@ func NamedExpressionToCommand(expr parser.Expr, name *string) Command {
 		value := StringValue(str)
 		return &LiteralCommand{value: value}
 
+	case parser.RegexLiteralExpr:
+		regex, err := NewRegex(t.Pattern)
+		if err != nil {
+			panic("Invalid regular expression: " + err.Error()) //Already checked by the parser
+		}
+		return &LiteralCommand{value: RegexValue(regex)}
+
 	case parser.IntegerLiteralExpr:
 		integer := t.Value
 		value := IntValue(integer)
@@ -917,72 +1164,80 @@ func NamedExpressionToCommand(expr parser.Expr, name *string) Command {
 		switch op {
 		case lexer.Add:
 			return &InvocationCommand{
-				Invoking: &ContextCommand{receiver: lhsCmd, variable: "plus"},
+				Invoking: newContextCommand(lhsCmd, "plus"),
 				args:     []Command{rhsCmd},
 			}
 		case lexer.Subtract:
 			return &InvocationCommand{
-				Invoking: &ContextCommand{receiver: lhsCmd, variable: "minus"},
+				Invoking: newContextCommand(lhsCmd, "minus"),
 				args:     []Command{rhsCmd},
 			}
 		case lexer.Multiply:
 			return &InvocationCommand{
-				Invoking: &ContextCommand{receiver: lhsCmd, variable: "times"},
+				Invoking: newContextCommand(lhsCmd, "times"),
 				args:     []Command{rhsCmd},
 			}
 		case lexer.Slash:
 			return &InvocationCommand{
-				Invoking: &ContextCommand{receiver: lhsCmd, variable: "divide"},
+				Invoking: newContextCommand(lhsCmd, "divide"),
 				args:     []Command{rhsCmd},
 			}
 		case lexer.Equals:
-			return &InvocationCommand{Invoking: &ContextCommand{receiver: lhsCmd, variable: "equals"},
+			return &InvocationCommand{Invoking: newContextCommand(lhsCmd, "equals"),
 				args: []Command{rhsCmd},
 			}
 		case lexer.NotEquals:
-			return NewAbstractCommand(func(ctx *Context) *ReturnedValue {
-				command := &InvocationCommand{Invoking: &ContextCommand{receiver: lhsCmd, variable: "equals"},
+			return &NotEqualsCommand{
+				equals: &InvocationCommand{Invoking: newContextCommand(lhsCmd, "equals"),
 					args: []Command{rhsCmd},
-				}
-
-				val := command.Exec(ctx).Unwrap()
-
-				asBool, ok := (*val).Value.(bool)
-				if !ok {
-					panic("equals function did not return bool")
-				}
-				return NonReturningValue(BooleanValue(!asBool))
-			})
+				},
+			}
 
 		case lexer.Mod:
-			return &InvocationCommand{Invoking: &ContextCommand{receiver: lhsCmd, variable: "mod"},
+			return &InvocationCommand{Invoking: newContextCommand(lhsCmd, "mod"),
 				args: []Command{rhsCmd},
 			}
 		}
 	case parser.FuncDefExpr:
+		body := ToCommand(t.Statement)
+		patterns := map[uint]parser.TuplePattern{}
+		for i, argument := range t.Arguments {
+			if argument.Pattern != nil {
+				patterns[uint(i)] = argument.Pattern.(parser.TuplePattern)
+			}
+		}
+		if len(patterns) != 0 {
+			body = &destructureParametersCommand{
+				patterns: patterns,
+				body:     body,
+			}
+		}
 		return &FunctionLiteralCommand{
 			name:       name,
 			parameters: t.Arguments,
 			returnType: t.ReturnType,
-			body:       ToCommand(t.Statement),
+			body:       body,
 		}
 
 	case parser.ContextExpr:
 		contextCmd := ExpressionToCommand(t.Context)
 		varName := t.Variable.Identifier
-		return &ContextCommand{
-			contextCmd,
-			varName,
-			util.Hash(varName),
-		}
+		return newContextCommand(contextCmd, varName)
 
 	case parser.AssignmentExpr:
-		//TODO contexts
 		name := t.Identifier
 		valueCmd := NamedExpressionToCommand(t.Value, &name)
+		if t.Context != nil {
+			return &PropertyAssignmentCommand{
+				receiver: ExpressionToCommand(t.Context),
+				Name:     name,
+				value:    valueCmd,
+			}
+		}
 		return &AssignmentCommand{
-			Name:  name,
-			value: valueCmd,
+			Name:       name,
+			value:      valueCmd,
+			hashedName: util.Hash(name),
 		}
 
 	case parser.IfElseExpr:
@@ -1028,6 +1283,25 @@ func NamedExpressionToCommand(expr parser.Expr, name *string) Command {
 		}
 		return &CollectionCommand{Elements: elements}
 
+	case parser.SetExpr:
+		elements := make([]Command, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = ExpressionToCommand(element)
+		}
+		return &SetCommand{Elements: elements}
+
+	case parser.RangeExpr:
+		return &RangeCommand{
+			start: ExpressionToCommand(t.Start),
+			end:   ExpressionToCommand(t.End),
+		}
+	case parser.TupleExpr:
+		elements := make([]Command, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = ExpressionToCommand(element)
+		}
+		return &TupleCommand{Elements: elements}
+
 	case parser.AccessExpr:
 		return &AccessCommand{
 			checking: ExpressionToCommand(t.Expr),
@@ -0,0 +1,413 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+//Tasks run functions on their own goroutines. Like every goroutine running Elara code in a Global, they take turns,
+//running while the rest of the script waits on something such as a channel, await or an HTTP request.
+//This means that a task can never see a variable half way through being changed, and that `let mut` variables shared between tasks are safe to reassign.
+//Tasks are started by a run of the interpreter, which waits for them to finish, and cancels them if it is cancelled or the script exits.
+
+var TaskType = NewEmptyType("Task")
+var SelectCaseType = NewEmptyType("SelectCase")
+
+//A Task is a function running on its own goroutine, started with spawn
+type Task struct {
+	done    chan struct{} //Closed once the task has finished
+	result  *Value
+	failure interface{} //What the task panicked with, if it failed
+	awaited bool        //Set once the task has been awaited, so that its failure isn't reported when the run finishes
+	cancel  context.CancelFunc
+}
+
+func (t *Task) String() string {
+	return "Task"
+}
+
+//Spawn starts running function on a new goroutine. The task's scope is a child of ctx, so it can use the same variables
+func Spawn(ctx *Context, loop *EventLoop, function *Function) *Task {
+	taskContext, cancel := context.WithCancel(ctx.limits.goContext())
+	task := &Task{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	ctx.retained = true //The task's scope is a child of this one, so it must outlive the call to spawn
+	loop.startTask()
+	go func() {
+		defer loop.finishTask()
+		defer close(task.done)
+		defer cancel()
+		ctx.global.acquire()
+		defer ctx.global.release()
+
+		scope := ctx.EnterScope("task", nil, 0)
+		scope.limits = ctx.limits.child(taskContext)
+		defer scope.Cleanup()
+		defer func() {
+			task.failure = recover()
+			if task.failure != nil {
+				loop.taskFailed(task)
+			}
+		}()
+		task.result = function.Exec(scope, []*Value{})
+	}()
+	return task
+}
+
+//Await waits for the task to finish, and returns its result.
+//If the task failed, the result is an Error, except if it exited or was denied a permission, which stops the awaiting code in the same way
+func (t *Task) Await(ctx *Context) *Value {
+	goContext := ctx.limits.goContext()
+	ctx.global.blocking(func() {
+		select {
+		case <-t.done:
+		case <-goContext.Done():
+		}
+	})
+	select {
+	case <-t.done:
+	default:
+		panic(&LimitError{Err: goContext.Err()})
+	}
+	t.awaited = true
+	switch failure := t.failure.(type) {
+	case nil:
+		return t.result
+	case *Exit, *PermissionError:
+		panic(failure)
+	case error:
+		return ErrorValue("Task failed: " + failure.Error())
+	default:
+		return ErrorValue(fmt.Sprint("Task failed: ", failure))
+	}
+}
+
+//checkUnawaited passes on the failures of tasks that finished without being awaited, which would otherwise be lost.
+//A task that exited or was denied a permission stops the script in the same way as if it had been awaited,
+//and other failures are written to stderr. Tasks that failed because they were cancelled aren't reported
+func checkUnawaited(ctx *Context, tasks []*Task) {
+	var stop interface{}
+	for _, task := range tasks {
+		if task.awaited {
+			continue
+		}
+		switch failure := task.failure.(type) {
+		case *Exit, *PermissionError:
+			if stop == nil {
+				stop = failure
+			}
+		case *LimitError:
+			if !errors.Is(failure, context.Canceled) {
+				writeStderr(ctx, fmt.Sprintf("Task failed: %v\n", failure))
+			}
+		default:
+			writeStderr(ctx, fmt.Sprintf("Task failed: %v\n", failure))
+		}
+	}
+	if stop != nil {
+		panic(stop)
+	}
+}
+
+//ChannelType is the type of a channel that can carry values of ElementType, eg Channel<Int>
+type ChannelType struct {
+	ElementType Type
+}
+
+func NewChannelTypeOf(elementType Type) *ChannelType {
+	return &ChannelType{ElementType: elementType}
+}
+
+func (t *ChannelType) Name() string {
+	return "Channel<" + t.ElementType.Name() + ">"
+}
+
+func (t *ChannelType) Accepts(otherType Type, ctx *Context) bool {
+	otherChannel, ok := otherType.(*ChannelType)
+	if !ok {
+		return false
+	}
+	return t.ElementType.Accepts(otherChannel.ElementType, ctx)
+}
+
+var anyChannelType = NewChannelTypeOf(AnyType)
+
+//A Channel sends values between tasks. Sending waits until there is room in the buffer, or until another task receives the value if it has no buffer
+type Channel struct {
+	ChannelType *ChannelType
+	values      chan *Value
+	closed      bool
+}
+
+func (c *Channel) String() string {
+	return c.ChannelType.Name()
+}
+
+func (c *Channel) checkElement(value *Value, ctx *Context) {
+	if !c.ChannelType.ElementType.Accepts(value.Type, ctx) {
+		panic("Cannot send " + value.String() + " to " + c.ChannelType.Name())
+	}
+}
+
+func (c *Channel) checkOpen() {
+	if c.closed {
+		panic("Cannot send to a closed channel")
+	}
+}
+
+//Send waits until the value can be sent, letting other tasks run in the meantime
+func (c *Channel) Send(ctx *Context, value *Value) {
+	c.checkElement(value, ctx)
+	c.checkOpen()
+	goContext := ctx.limits.goContext()
+	sent := false
+	ctx.global.blocking(func() {
+		defer recoverClosedSend()
+		select {
+		case c.values <- value:
+			sent = true
+		case <-goContext.Done():
+		}
+	})
+	if sent {
+		return
+	}
+	c.checkOpen() //The channel may have been closed while waiting
+	panic(&LimitError{Err: goContext.Err()})
+}
+
+//recoverClosedSend stops a channel that was closed while a task was waiting to send to it from crashing the process.
+//The task can then panic in the usual way when it checks the channel again. Anything else, eg an exit, is passed on
+func recoverClosedSend() {
+	if r := recover(); r != nil {
+		if err, isRuntimeError := r.(runtime.Error); !isRuntimeError || err.Error() != "send on closed channel" {
+			panic(r)
+		}
+	}
+}
+
+//Receive waits for a value, returning false once the channel is closed and every value has been received
+func (c *Channel) Receive(ctx *Context) (*Value, bool) {
+	goContext := ctx.limits.goContext()
+	var value *Value
+	received, open := false, false
+	ctx.global.blocking(func() {
+		select {
+		case value, open = <-c.values:
+			received = true
+		case <-goContext.Done():
+		}
+	})
+	if !received {
+		panic(&LimitError{Err: goContext.Err()})
+	}
+	return value, open
+}
+
+//Close stops any more values being sent. Values that have already been sent can still be received.
+//Closing only happens while running Elara code, so it never happens at the same time as checkOpen
+func (c *Channel) Close() {
+	if c.closed {
+		panic("Channel is already closed")
+	}
+	c.closed = true
+	close(c.values)
+}
+
+//A selectCase is one of the operations that select waits for. Exactly one of channel and after is set
+type selectCase struct {
+	channel *Channel
+	send    *Value //The value to send, or nil to receive
+	after   time.Duration
+	handler *Function
+}
+
+func (s *selectCase) String() string {
+	return "SelectCase"
+}
+
+//Select waits until one of the cases can go ahead, then runs its handler and returns the result.
+//Receiving from a closed channel gives the handler Unit.
+func Select(ctx *Context, loop *EventLoop, cases []*selectCase) *Value {
+	goContext := ctx.limits.goContext()
+	selectCases := make([]reflect.SelectCase, len(cases)+1)
+	var stops []func()
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+	for i, c := range cases {
+		switch {
+		case c.channel == nil:
+			after, stop := loop.clock.After(c.after)
+			stops = append(stops, stop)
+			selectCases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(after)}
+		case c.send != nil:
+			c.channel.checkOpen()
+			selectCases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(c.channel.values), Send: reflect.ValueOf(c.send)}
+		default:
+			selectCases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.channel.values)}
+		}
+	}
+	selectCases[len(cases)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(goContext.Done())}
+
+	chosen := -1
+	var received reflect.Value
+	var open bool
+	ctx.global.blocking(func() {
+		defer recoverClosedSend()
+		chosen, received, open = reflect.Select(selectCases)
+	})
+	for _, stop := range stops {
+		stop() //So that a VirtualClock doesn't move to a timeout that wasn't chosen
+	}
+	if chosen == -1 {
+		panic("Cannot send to a closed channel")
+	}
+	if chosen == len(cases) {
+		panic(&LimitError{Err: goContext.Err()})
+	}
+
+	c := cases[chosen]
+	if c.channel == nil || c.send != nil {
+		return c.handler.Exec(ctx, []*Value{})
+	}
+	value := UnitValue()
+	if open {
+		value = received.Interface().(*Value)
+	}
+	return c.handler.Exec(ctx, []*Value{value})
+}
+
+//InitConcurrency defines spawn, channel and select, and the functions on tasks and channels
+func InitConcurrency(ctx *Context, loop *EventLoop) {
+	concurrencyFunction := func(name string, returnType Type, parameters []Parameter, body func(ctx *Context) *Value) {
+		for i := range parameters {
+			parameters[i].Position = uint(i)
+		}
+		defineImpure(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: parameters,
+				ReturnType: returnType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				return NonReturningValue(body(ctx))
+			}),
+		})
+	}
+
+	//spawn runs a function on its own task, eg let task = spawn(() => get(url))
+	concurrencyFunction("spawn", TaskType, []Parameter{{Name: "function", Type: functionTypeOf(AnyType)}}, func(ctx *Context) *Value {
+		return NewValue(TaskType, Spawn(ctx, loop, ctx.FindParameter(0).Value.(*Function)))
+	})
+
+	concurrencyFunction("await", AnyType, []Parameter{{Name: "this", Type: TaskType}}, func(ctx *Context) *Value {
+		return ctx.FindParameter(0).Value.(*Task).Await(ctx)
+	})
+
+	//Cancelling a task stops it the next time it calls a function, goes round a loop, or waits. Awaiting it then gives an Error
+	concurrencyFunction("cancel", UnitType, []Parameter{{Name: "this", Type: TaskType}}, func(ctx *Context) *Value {
+		ctx.FindParameter(0).Value.(*Task).cancel()
+		return UnitValue()
+	})
+
+	concurrencyFunction("isDone", BooleanType, []Parameter{{Name: "this", Type: TaskType}}, func(ctx *Context) *Value {
+		select {
+		case <-ctx.FindParameter(0).Value.(*Task).done:
+			return BooleanValue(true)
+		default:
+			return BooleanValue(false)
+		}
+	})
+
+	//channel<T>(capacity) creates a channel for values of type T, which can hold capacity values before sending waits
+	elementType := NewTypeParameter("T", nil)
+	define(ctx, "channel", &Function{
+		Signature: Signature{
+			TypeParameters: []*TypeParameter{elementType},
+			Parameters: []Parameter{
+				{
+					Name: "capacity",
+					Type: IntType,
+				},
+			},
+			ReturnType: NewChannelTypeOf(elementType),
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			capacity := ctx.FindParameter(0).Value.(int64)
+			if capacity < 0 {
+				panic("Channel capacity must not be negative")
+			}
+			channelType := NewChannelTypeOf(ctx.FindType(elementType.Name()))
+			return NonReturningValue(NewValue(channelType, &Channel{
+				ChannelType: channelType,
+				values:      make(chan *Value, capacity),
+			}))
+		}),
+	})
+
+	concurrencyFunction("send", UnitType, []Parameter{{Name: "this", Type: anyChannelType}, {Name: "value", Type: AnyType}}, func(ctx *Context) *Value {
+		ctx.FindParameter(0).Value.(*Channel).Send(ctx, ctx.FindParameter(1))
+		return UnitValue()
+	})
+
+	//receive gives the next value, or Unit once the channel is closed and every value has been received
+	concurrencyFunction("receive", AnyType, []Parameter{{Name: "this", Type: anyChannelType}}, func(ctx *Context) *Value {
+		value, open := ctx.FindParameter(0).Value.(*Channel).Receive(ctx)
+		if !open {
+			return UnitValue()
+		}
+		return value
+	})
+
+	concurrencyFunction("close", UnitType, []Parameter{{Name: "this", Type: anyChannelType}}, func(ctx *Context) *Value {
+		ctx.FindParameter(0).Value.(*Channel).Close()
+		return UnitValue()
+	})
+
+	//The cases for select: onReceive(channel, (value) => ...), onSend(channel, value, () => ...) and onTimeout(ms, () => ...)
+	concurrencyFunction("onReceive", SelectCaseType, []Parameter{{Name: "channel", Type: anyChannelType}, {Name: "handler", Type: functionTypeOf(AnyType, AnyType)}}, func(ctx *Context) *Value {
+		return NewValue(SelectCaseType, &selectCase{
+			channel: ctx.FindParameter(0).Value.(*Channel),
+			handler: ctx.FindParameter(1).Value.(*Function),
+		})
+	})
+
+	concurrencyFunction("onSend", SelectCaseType, []Parameter{{Name: "channel", Type: anyChannelType}, {Name: "value", Type: AnyType}, {Name: "handler", Type: functionTypeOf(AnyType)}}, func(ctx *Context) *Value {
+		channel := ctx.FindParameter(0).Value.(*Channel)
+		value := ctx.FindParameter(1)
+		channel.checkElement(value, ctx)
+		return NewValue(SelectCaseType, &selectCase{
+			channel: channel,
+			send:    value,
+			handler: ctx.FindParameter(2).Value.(*Function),
+		})
+	})
+
+	concurrencyFunction("onTimeout", SelectCaseType, []Parameter{{Name: "ms", Type: IntType}, {Name: "handler", Type: functionTypeOf(AnyType)}}, func(ctx *Context) *Value {
+		return NewValue(SelectCaseType, &selectCase{
+			after:   time.Duration(ctx.FindParameter(0).Value.(int64)) * time.Millisecond,
+			handler: ctx.FindParameter(1).Value.(*Function),
+		})
+	})
+
+	//select waits for the first case that can go ahead, and returns what its handler returns
+	concurrencyFunction("select", AnyType, []Parameter{{Name: "cases", Type: NewCollectionTypeOf(SelectCaseType)}}, func(ctx *Context) *Value {
+		elements := collectionParameter(ctx, 0).Elements()
+		if len(elements) == 0 {
+			panic("select needs at least one case")
+		}
+		cases := make([]*selectCase, len(elements))
+		for i, element := range elements {
+			cases[i] = element.Value.(*selectCase)
+		}
+		return Select(ctx, loop, cases)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/ElaraLang/elara/util"
 	"math"
+	"sync"
+	"sync/atomic"
 )
 
 type Context struct {
@@ -19,18 +21,88 @@ type Context struct {
 	types      map[string]Type
 	parent     *Context
 	function   *Function //Will only be nil if this is a Function scope
+
+	lazyChain   *lazyFrame   //The lazy bindings being evaluated by the current chain of execution
+	retained    bool         //Set when something (such as a task) still refers to this context after its scope exits
+	lazies      []*LazyValue //The lazy bindings defined in this scope, which take a snapshot of it when it exits if they haven't been evaluated
+	global      *Global      //Shared by every context of an Interpreter
+	limits      *limits      //The limits of the Interpreter running this context
+	permissions *Permissions //What the Interpreter running this context is allowed to do
+	parallel    bool         //Set while running a function given to parallelMap, parallelFilter or parallelFold
+}
+
+//A Global holds the state shared by every context of an Interpreter: the namespaces that have been declared,
+//and a pool of contexts to reuse. Interpreters don't share a Global unless they are given one with WithGlobal,
+//so scripts running in different interpreters can't see each other's namespaces.
+//
+//Only one goroutine runs Elara code in a Global at a time, so that tasks, HTTP handlers and interpreters sharing the Global
+//never change contexts underneath each other. Natives that wait, such as await or receiving from a channel, let other goroutines run while they wait.
+type Global struct {
+	mutex      sync.RWMutex //Interpreters sharing a Global can run on different goroutines
+	namespaces map[string][]*Context
+	pool       sync.Pool
+	running    sync.Mutex //Held by the goroutine that is running Elara code
+
+	equalsExtensions int32    //How many equals extensions have been defined, so that Value.Equals can skip looking for one
+	goTypes          sync.Map //The GoType of each reflect.Type that has been converted, see NewGoType
+}
+
+func NewGlobal() *Global {
+	global := &Global{
+		namespaces: map[string][]*Context{},
+	}
+	global.pool.New = func() interface{} {
+		return &Context{
+			variables:   map[uint64][]*Variable{},
+			parameters:  []*Value{},
+			namespace:   "",
+			name:        "",
+			contextPath: map[string][]*Context{},
+			types:       map[string]Type{},
+			parent:      nil,
+			function:    nil,
+			extensions:  map[Type]map[string]*Extension{},
+			global:      global,
+		}
+	}
+	return global
+}
+
+func (g *Global) acquire() {
+	g.running.Lock()
+}
+
+func (g *Global) release() {
+	g.running.Unlock()
+}
+
+//blocking lets other goroutines run Elara code while wait is running. It must only be called by the goroutine that is running Elara code
+func (g *Global) blocking(wait func()) {
+	g.release()
+	defer g.acquire()
+	wait()
+}
+
+//wait runs a function that waits for another goroutine. If this context's goroutine is running Elara code, other goroutines can run while it waits.
+//Every run, task and request is given limits when it takes hold of the Global, and parallel workers run while their caller holds it, so they must not let go of it
+func (c *Context) wait(wait func()) {
+	if c.limits == nil || c.parallel {
+		wait()
+		return
+	}
+	c.global.blocking(wait)
 }
 
-var globalContext = &Context{
-	namespace:   "__global__",
-	name:        "__global__",
-	variables:   map[uint64][]*Variable{},
-	extensions:  map[Type]map[string]*Extension{},
-	parameters:  []*Value{},
-	contextPath: map[string][]*Context{},
+func (g *Global) declare(namespace string, context *Context) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.namespaces[namespace] = append(g.namespaces[namespace], context)
+}
 
-	types:    map[string]Type{},
-	function: nil,
+func (g *Global) namespace(namespace string) []*Context {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.namespaces[namespace]
 }
 
 func (c *Context) Init(namespace string) {
@@ -38,10 +110,11 @@ func (c *Context) Init(namespace string) {
 		panic("Context has already been initialized!")
 	}
 	c.namespace = namespace
-	globalContext.contextPath[c.namespace] = append(globalContext.contextPath[c.namespace], c)
+	c.global.declare(c.namespace, c)
 }
 
 func (c *Context) DefineVariableWithHash(hash uint64, value *Variable) {
+	value.parallel = c.parallel
 	vars := c.variables[hash]
 	vars = append(vars, value)
 	c.variables[hash] = vars
@@ -52,46 +125,68 @@ func (c *Context) DefineVariable(value *Variable) {
 }
 
 func (c *Context) FindFunction(hash uint64, signature *Signature) *Function {
+	variable := c.findFunctionVariable(hash, signature)
+	if variable == nil {
+		return nil
+	}
+	if !variable.AccessibleFrom(c.namespace) {
+		panic(restrictedAccessError(variable.Name, variable.Namespace, c.namespace))
+	}
+	return variable.Get(c).Value.(*Function)
+}
+
+func (c *Context) findFunctionVariable(hash uint64, signature *Signature) *Variable {
 	vars := c.variables[hash]
 	if vars != nil {
 		matching := make([]*Variable, 0)
 		for _, variable := range vars {
-			asFunction, isFunction := variable.Value.Value.(*Function)
-			if isFunction {
-				if asFunction.Signature.Accepts(signature, c, false) {
-					matching = append(matching, variable)
-				}
+			functionSignature := variable.functionSignature() //Doesn't force lazy bindings, as only the one that matches is used
+			if functionSignature != nil && functionSignature.Accepts(signature, c, false) {
+				matching = append(matching, variable)
 			}
 		}
 		if len(matching) > 1 {
 			_ = fmt.Errorf("multiple matching functions with name %s and signature %s", matching[0].Name, signature.String())
 		}
 		if len(matching) != 0 {
-			return matching[0].Value.Value.(*Function)
+			return matching[0]
 		}
 	}
 
 	if c.parent != nil {
-		parFound := c.parent.FindFunction(hash, signature)
+		parFound := c.parent.findFunctionVariable(hash, signature)
 		if parFound != nil {
 			return parFound
 		}
 	}
+	var hidden *Variable
 	for _, contexts := range c.contextPath {
 		for _, context := range contexts {
-			v := context.FindFunction(hash, signature)
+			v := context.findFunctionVariable(hash, signature)
 			if v != nil {
+				if !v.AccessibleFrom(c.namespace) {
+					hidden = v //Keep looking in case another namespace has an accessible one
+					continue
+				}
 				return v
 			}
 		}
 	}
-	return nil
+	return hidden
 }
+
 func (c *Context) FindVariable(hash uint64) *Variable {
 	variable, _ := c.FindVariableMaxDepth(hash, -1)
+	if variable != nil && !variable.AccessibleFrom(c.namespace) {
+		panic(restrictedAccessError(variable.Name, variable.Namespace, c.namespace))
+	}
 	return variable
 }
 
+func restrictedAccessError(name string, owner string, from string) string {
+	return fmt.Sprintf("%s is restricted to namespace %s and cannot be accessed from %s", name, owner, from)
+}
+
 //TODO this needs optimising, it's a MASSIVE hotspot
 func (c *Context) FindVariableMaxDepth(hash uint64, maxDepth int) (*Variable, int) {
 	vars := c.variables[hash]
@@ -118,14 +213,22 @@ func (c *Context) FindVariableMaxDepth(hash uint64, maxDepth int) (*Variable, in
 		}
 	}
 
+	var hidden *Variable
 	for _, contexts := range c.contextPath {
 		for _, context := range contexts {
 			v, _ := context.FindVariableMaxDepth(hash, maxDepth-i)
 			if v != nil {
+				if !v.AccessibleFrom(c.namespace) {
+					hidden = v //Keep looking in case another namespace has an accessible one
+					continue
+				}
 				return v, i //0 for a variable from an import?
 			}
 		}
 	}
+	if hidden != nil {
+		return hidden, i
+	}
 
 	return nil, -1
 }
@@ -147,6 +250,12 @@ func (c *Context) FindType(name string) Type {
 	if ok {
 		return t
 	}
+	if c.parent != nil {
+		t := c.parent.FindType(name)
+		if t != nil {
+			return t
+		}
+	}
 	for _, contexts := range c.contextPath {
 		for _, context := range contexts {
 			t := context.FindType(name)
@@ -159,17 +268,34 @@ func (c *Context) FindType(name string) Type {
 }
 
 func (c *Context) EnterScope(name string, function *Function, paramLength uint) *Context {
-	scope := NewContext(false)
+	scope := c.global.NewContext(false)
 	scope.parent = c
-	scope.namespace = c.name
+	scope.namespace = c.namespace
 	scope.name = name
 	scope.contextPath = c.contextPath
 	scope.function = function
 	scope.parameters = make([]*Value, paramLength)
 	scope.extensions = c.extensions
+	scope.lazyChain = c.lazyChain
+	scope.limits = c.limits
+	scope.permissions = c.permissions
+	scope.parallel = c.parallel
 	return scope
 }
 
+//checkNotParallel panics if the context is running a parallel function, which can't change anything shared with the other workers
+func (c *Context) checkNotParallel(action string) {
+	if c.parallel {
+		panic("Cannot " + action + " from a parallel function")
+	}
+}
+
+//canCache returns whether commands and types can fill in their caches. They are shared between parallel workers,
+//so only the caller fills them in, and workers work out what they need each time
+func (c *Context) canCache() bool {
+	return !c.parallel
+}
+
 func (c *Context) FindConstructor(name string) *Value {
 
 	t := c.FindType(name)
@@ -183,6 +309,7 @@ func (c *Context) FindConstructor(name string) *Value {
 	if asStruct.constructor != nil {
 		return asStruct.constructor
 	}
+	cache := c.canCache()
 
 	constructorParams := make([]Parameter, 0)
 	i := uint(0)
@@ -199,18 +326,27 @@ func (c *Context) FindConstructor(name string) *Value {
 
 	constructor := &Function{
 		Signature: Signature{
-			Parameters: constructorParams,
-			ReturnType: t,
+			TypeParameters: asStruct.TypeParameters,
+			Parameters:     constructorParams,
+			ReturnType:     t,
 		},
 		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
 			values := make(map[string]*Value, len(constructorParams))
 			for _, param := range constructorParams {
 				values[param.Name] = ctx.FindParameter(param.Position)
 			}
+			instanceType := asStruct
+			if len(asStruct.TypeParameters) != 0 {
+				typeArguments := make([]Type, len(asStruct.TypeParameters))
+				for i, parameter := range asStruct.TypeParameters {
+					typeArguments[i] = ctx.FindType(parameter.name)
+				}
+				instanceType = asStruct.Specialise(typeArguments, ctx)
+			}
 			return NonReturningValue(&Value{
-				Type: t,
+				Type: instanceType,
 				Value: &Instance{
-					Type:   asStruct,
+					Type:   instanceType,
 					Values: values,
 				},
 			})
@@ -222,14 +358,20 @@ func (c *Context) FindConstructor(name string) *Value {
 		Type:  NewFunctionType(constructor),
 		Value: constructor,
 	}
-	asStruct.constructor = constructorVal
+	if cache {
+		asStruct.constructor = constructorVal
+	}
 	return constructorVal
 }
 
 func (c *Context) Import(namespace string) {
-	contexts := globalContext.contextPath[namespace]
+	contexts := c.global.namespace(namespace)
 	if contexts == nil {
-		panic("Nothing found in namespace " + namespace)
+		module := c.global.nativeModule(namespace)
+		if module == nil {
+			panic("Nothing found in namespace " + namespace)
+		}
+		contexts = []*Context{module}
 	}
 	ns := c.contextPath[namespace]
 	if ns == nil {
@@ -243,7 +385,7 @@ func (c *Context) Import(namespace string) {
 func (c *Context) string() string {
 	s := ""
 	for key, values := range c.variables {
-		s += fmt.Sprintf("%s = [\n", key)
+		s += fmt.Sprintf("%d = [\n", key)
 		for _, val := range values {
 			s += fmt.Sprintf("%s \n", val.String())
 		}
@@ -273,11 +415,19 @@ func (c *Context) DefineExtension(receiverType Type, name string, value *Extensi
 	}
 	extensions[name] = value
 	c.extensions[receiverType] = extensions
+	if name == "equals" {
+		atomic.AddInt32(&c.global.equalsExtensions, 1)
+	}
 }
 
 func (c *Context) FindExtension(receiverType Type, name string) *Extension {
 	extensions, present := c.extensions[receiverType]
 	if !present {
+		asStruct, isStruct := receiverType.(*StructType)
+		if isStruct && asStruct.generic != nil {
+			//Extensions are defined on the generic struct, not each specialisation of it
+			return c.FindExtension(asStruct.generic, name)
+		}
 		extensions = map[string]*Extension{}
 	}
 	return extensions[name]
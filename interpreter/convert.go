@@ -0,0 +1,410 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+//Conversions between Go and Elara values, used when embedding Elara in a Go program.
+//Go integers become Ints, floats become Floats, slices and arrays become collections and maps become maps.
+//In the other direction, collections become []interface{}, maps become map[string]interface{} if every key is a String,
+//and struct instances become map[string]interface{} of their properties.
+//Go values that Elara has no equivalent for, such as structs, are wrapped in a GoValue, and converted back to the wrapped value.
+
+var valueType = reflect.TypeOf((*Value)(nil))
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+//Error is also a Go error, so that Elara Errors can be returned to Go code
+func (e *Error) Error() string {
+	return e.Message
+}
+
+//ToValue converts a Go value into an Elara value. *Values are returned unchanged, and nil becomes Unit.
+func ToValue(ctx *Context, goValue interface{}) (*Value, error) {
+	switch t := goValue.(type) {
+	case nil:
+		return UnitValue(), nil
+	case *Value:
+		return t, nil
+	case error:
+		return ErrorValue(t.Error()), nil
+	case *Function:
+		return NewValue(NewFunctionType(t), t), nil
+	}
+	return reflectToValue(ctx, reflect.ValueOf(goValue))
+}
+
+func reflectToValue(ctx *Context, value reflect.Value) (*Value, error) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntValue(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return IntValue(int64(value.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return FloatValue(value.Float()), nil
+	case reflect.Bool:
+		return BooleanValue(value.Bool()), nil
+	case reflect.String:
+		return StringValue(value.String()), nil
+	case reflect.Interface, reflect.Ptr:
+		if value.IsNil() {
+			return UnitValue(), nil
+		}
+		if value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Struct {
+			return newGoValue(ctx, value), nil
+		}
+		return ToValue(ctx, value.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		elements := make([]*Value, value.Len())
+		for i := range elements {
+			element, err := ToValue(ctx, value.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return CollectionValue(commonElementType(ctx, elements), elements), nil
+	case reflect.Map:
+		keys := value.MapKeys()
+		//Go maps are unordered, so sort the keys to give the same Elara map every time
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		entries := make([]*Entry, len(keys))
+		for i, key := range keys {
+			keyValue, err := ToValue(ctx, key.Interface())
+			if err != nil {
+				return nil, err
+			}
+			entryValue, err := ToValue(ctx, value.MapIndex(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = &Entry{Key: keyValue, Value: entryValue}
+		}
+		mapValue := MapOf(ctx, entries)
+		return NewValue(mapValue.MapType, mapValue), nil
+	case reflect.Func:
+		if value.IsNil() {
+			return UnitValue(), nil
+		}
+		function, err := NewGoFunction(ctx, value.Type().String(), value.Interface())
+		if err == nil {
+			return NewValue(NewFunctionType(function), function), nil
+		}
+	}
+	return newGoValue(ctx, value), nil
+}
+
+//FromValue converts an Elara value into the closest Go value. Unit becomes nil, and Errors become Go errors.
+//Functions are returned as *Function, so that they can be called later.
+func FromValue(value *Value) interface{} {
+	switch t := value.Value.(type) {
+	case *Collection:
+		if t.ElementType == CharType {
+			return t.elemsAsString()
+		}
+		converted := make([]interface{}, t.Size())
+		t.ForEach(func(i int, element *Value) bool {
+			converted[i] = FromValue(element)
+			return true
+		})
+		return converted
+	case *Set:
+		return fromValues(t.Elements())
+	case *Tuple:
+		return fromValues(t.Elements)
+	case *Map:
+		entries := t.Entries()
+		allStrings := true
+		for _, entry := range entries {
+			if _, isString := entry.Key.Value.(string); !isString {
+				allStrings = false
+				break
+			}
+		}
+		if allStrings {
+			converted := make(map[string]interface{}, len(entries))
+			for _, entry := range entries {
+				converted[entry.Key.Value.(string)] = FromValue(entry.Value)
+			}
+			return converted
+		}
+		converted := make(map[interface{}]interface{}, len(entries))
+		for _, entry := range entries {
+			key := FromValue(entry.Key)
+			if !reflect.TypeOf(key).Comparable() {
+				key = entry.Key.String() //Collections can't be Go map keys
+			}
+			converted[key] = FromValue(entry.Value)
+		}
+		return converted
+	case *Instance:
+		converted := make(map[string]interface{}, len(t.Type.Properties))
+		for _, property := range t.Type.Properties {
+			propertyValue := t.dataValue(property)
+			if propertyValue != nil {
+				converted[property.Name] = FromValue(propertyValue)
+			}
+		}
+		return converted
+	case *GoValue:
+		return t.Interface()
+	}
+	return value.Value
+}
+
+func fromValues(values []*Value) []interface{} {
+	converted := make([]interface{}, len(values))
+	for i, value := range values {
+		converted[i] = FromValue(value)
+	}
+	return converted
+}
+
+//goTypeToElara gives the Elara type that Go values of a type are converted to
+func goTypeToElara(ctx *Context, goType reflect.Type) (Type, error) {
+	if goType == valueType {
+		return AnyType, nil
+	}
+	if goType == errorType {
+		return ErrorType, nil
+	}
+	switch goType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return IntType, nil
+	case reflect.Float32, reflect.Float64:
+		return FloatType, nil
+	case reflect.Bool:
+		return BooleanType, nil
+	case reflect.String:
+		return StringType, nil
+	case reflect.Interface, reflect.Func:
+		return AnyType, nil
+	case reflect.Ptr:
+		if goType.Elem().Kind() == reflect.Struct {
+			return NewGoType(ctx, goType), nil
+		}
+	case reflect.Struct, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return NewGoType(ctx, goType), nil
+	case reflect.Slice, reflect.Array:
+		elementType, err := goTypeToElara(ctx, goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return NewCollectionTypeOf(elementType), nil
+	case reflect.Map:
+		keyType, err := goTypeToElara(ctx, goType.Key())
+		if err != nil {
+			return nil, err
+		}
+		elementType, err := goTypeToElara(ctx, goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &MapType{KeyType: keyType, ValueType: elementType}, nil
+	}
+	return nil, fmt.Errorf("Go type %s has no equivalent Elara type", goType)
+}
+
+//convertToGo converts an Elara value into a Go value of the given type, failing if it doesn't fit
+func convertToGo(value *Value, goType reflect.Type) (reflect.Value, error) {
+	if goType == valueType {
+		return reflect.ValueOf(value), nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("cannot convert %s of type %s to Go type %s", value.String(), value.Type.Name(), goType)
+	}
+	if wrapped, isGo := value.Value.(*GoValue); isGo {
+		if wrapped.value.Type().AssignableTo(goType) {
+			return wrapped.value, nil
+		}
+		if wrapped.value.Kind() == reflect.Ptr && wrapped.value.Type().Elem() == goType {
+			return wrapped.value.Elem(), nil //Structs are held by a pointer, so this copies the struct
+		}
+		return reflect.Value{}, mismatch()
+	}
+
+	switch goType.Kind() {
+	case reflect.Interface:
+		converted := FromValue(value)
+		if converted == nil {
+			return reflect.Zero(goType), nil
+		}
+		convertedValue := reflect.ValueOf(converted)
+		if !convertedValue.Type().AssignableTo(goType) {
+			return reflect.Value{}, mismatch()
+		}
+		return convertedValue.Convert(goType), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, isInt := value.Value.(int64)
+		result := reflect.New(goType).Elem()
+		if !isInt || result.OverflowInt(integer) {
+			return reflect.Value{}, mismatch()
+		}
+		result.SetInt(integer)
+		return result, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		integer, isInt := value.Value.(int64)
+		result := reflect.New(goType).Elem()
+		if !isInt || integer < 0 || result.OverflowUint(uint64(integer)) {
+			return reflect.Value{}, mismatch()
+		}
+		result.SetUint(uint64(integer))
+		return result, nil
+	case reflect.Float32, reflect.Float64:
+		result := reflect.New(goType).Elem()
+		switch number := value.Value.(type) {
+		case float64:
+			result.SetFloat(number)
+		case int64:
+			result.SetFloat(float64(number))
+		default:
+			return reflect.Value{}, mismatch()
+		}
+		return result, nil
+	case reflect.Bool:
+		boolean, isBool := value.Value.(bool)
+		if !isBool {
+			return reflect.Value{}, mismatch()
+		}
+		return reflect.ValueOf(boolean).Convert(goType), nil
+	case reflect.String:
+		switch t := value.Value.(type) {
+		case string:
+			return reflect.ValueOf(t).Convert(goType), nil
+		case *Collection:
+			if t.ElementType == CharType {
+				return reflect.ValueOf(t.elemsAsString()).Convert(goType), nil
+			}
+		}
+		return reflect.Value{}, mismatch()
+	case reflect.Slice:
+		var elements []*Value
+		switch t := value.Value.(type) {
+		case *Collection:
+			elements = t.Elements()
+		case *Set:
+			elements = t.Elements()
+		case *Tuple:
+			elements = t.Elements
+		default:
+			return reflect.Value{}, mismatch()
+		}
+		result := reflect.MakeSlice(goType, len(elements), len(elements))
+		for i, element := range elements {
+			converted, err := convertToGo(element, goType.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(converted)
+		}
+		return result, nil
+	case reflect.Map:
+		mapValue, isMap := value.Value.(*Map)
+		if !isMap {
+			return reflect.Value{}, mismatch()
+		}
+		result := reflect.MakeMapWithSize(goType, mapValue.Size())
+		for _, entry := range mapValue.Entries() {
+			key, err := convertToGo(entry.Key, goType.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			converted, err := convertToGo(entry.Value, goType.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetMapIndex(key, converted)
+		}
+		return result, nil
+	}
+	return reflect.Value{}, mismatch()
+}
+
+//NewGoFunction wraps a Go function so that it can be called from Elara, converting its arguments and results.
+//The Go function can return nothing, a single value, or a value and an error. A non-nil error is returned to Elara as an Error.
+func NewGoFunction(ctx *Context, name string, goFunction interface{}) (*Function, error) {
+	function := reflect.ValueOf(goFunction)
+	functionType := function.Type()
+	if functionType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s is a %s, not a function", name, functionType)
+	}
+	if functionType.IsVariadic() {
+		return nil, errors.New("variadic Go functions can't be called from Elara")
+	}
+
+	parameters := make([]Parameter, functionType.NumIn())
+	for i := range parameters {
+		parameterType, err := goTypeToElara(ctx, functionType.In(i))
+		if err != nil {
+			return nil, err
+		}
+		parameters[i] = Parameter{
+			Name:     fmt.Sprintf("arg%d", i),
+			Type:     parameterType,
+			Position: uint(i),
+		}
+	}
+
+	var returnType Type
+	returnsError := functionType.NumOut() != 0 && functionType.Out(functionType.NumOut()-1) == errorType
+	switch {
+	case functionType.NumOut() == 0:
+		returnType = UnitType
+	case functionType.NumOut() == 1 && returnsError:
+		returnType = orError(UnitType)
+	case functionType.NumOut() == 1:
+		resultType, err := goTypeToElara(ctx, functionType.Out(0))
+		if err != nil {
+			return nil, err
+		}
+		returnType = resultType
+	case functionType.NumOut() == 2 && returnsError:
+		resultType, err := goTypeToElara(ctx, functionType.Out(0))
+		if err != nil {
+			return nil, err
+		}
+		returnType = orError(resultType)
+	default:
+		return nil, errors.New("Go functions called from Elara must return at most a value and an error")
+	}
+
+	return &Function{
+		name:   &name,
+		impure: true, //Go functions could do anything, so parallel functions can't call them
+		Signature: Signature{
+			Parameters: parameters,
+			ReturnType: returnType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			arguments := make([]reflect.Value, len(parameters))
+			for i := range arguments {
+				argument, err := convertToGo(ctx.FindParameter(uint(i)), functionType.In(i))
+				if err != nil {
+					panic(fmt.Sprintf("Invalid argument %d for %s: %s", i, name, err.Error()))
+				}
+				arguments[i] = argument
+			}
+			results := function.Call(arguments)
+			if returnsError {
+				err := results[len(results)-1]
+				if !err.IsNil() {
+					return NonReturningValue(ErrorValue(err.Interface().(error).Error()))
+				}
+				results = results[:len(results)-1]
+			}
+			if len(results) == 0 {
+				return NonReturningValue(UnitValue())
+			}
+			result, err := ToValue(ctx, results[0].Interface())
+			if err != nil {
+				panic(err.Error())
+			}
+			return NonReturningValue(result)
+		}),
+	}, nil
+}
@@ -0,0 +1,161 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/ElaraLang/elara/parser"
+	"reflect"
+)
+
+//DestructuringCommand binds every name in a pattern to the matching part of a value, eg let (x, y) = point
+type DestructuringCommand struct {
+	pattern    parser.TuplePattern
+	Mutable    bool
+	Restricted bool
+	Type       parser.Type
+	value      Command
+}
+
+func (c *DestructuringCommand) Exec(ctx *Context) *ReturnedValue {
+	value := c.value.Exec(ctx).Unwrap()
+	if c.Type != nil {
+		expected := FromASTType(c.Type, ctx)
+		if !expected.Accepts(value.Type, ctx) {
+			panic("Cannot use value of type " + value.Type.Name() + " in place of " + expected.Name() + " for destructuring binding")
+		}
+	}
+	bindPattern(ctx, c.pattern, value, c.Mutable, c.Restricted)
+	return NilValue()
+}
+
+//destructureParametersCommand binds destructured function parameters before running the function body
+type destructureParametersCommand struct {
+	patterns map[uint]parser.TuplePattern //Parameter position -> pattern
+	body     Command
+}
+
+func (c *destructureParametersCommand) Exec(ctx *Context) *ReturnedValue {
+	for position, pattern := range c.patterns {
+		bindPattern(ctx, pattern, ctx.FindParameter(position), false, false)
+	}
+	return c.body.Exec(ctx)
+}
+
+//PatternType is the type of a destructured parameter. It accepts tuples, structs and collections whose parts are accepted by the types that the pattern gives them,
+//so that overloads can be told apart by their destructured parameters
+type PatternType struct {
+	ElementTypes []Type //AnyType for parts that aren't given a type
+}
+
+//NewPatternType creates the type of a pattern, resolving the types of its parts in ctx
+func NewPatternType(pattern parser.TuplePattern, ctx *Context) *PatternType {
+	elementTypes := make([]Type, len(pattern.Elements))
+	for i, element := range pattern.Elements {
+		switch t := element.(type) {
+		case parser.TuplePattern:
+			elementTypes[i] = NewPatternType(t, ctx)
+		case parser.IdentifierPattern:
+			if t.Type == nil {
+				elementTypes[i] = AnyType
+			} else {
+				elementTypes[i] = FromASTType(t.Type, ctx)
+			}
+		}
+	}
+	return &PatternType{ElementTypes: elementTypes}
+}
+
+func (t *PatternType) Name() string {
+	return (&TupleType{ElementTypes: t.ElementTypes}).Name()
+}
+
+func (t *PatternType) Accepts(otherType Type, ctx *Context) bool {
+	switch other := otherType.(type) {
+	case *PatternType:
+		return t.acceptsParts(other.ElementTypes, ctx)
+	case *TupleType:
+		return t.acceptsParts(other.ElementTypes, ctx)
+	case *StructType:
+		propertyTypes := make([]Type, len(other.Properties))
+		for i, property := range other.Properties {
+			propertyTypes[i] = property.Type
+		}
+		return t.acceptsParts(propertyTypes, ctx)
+	case *CollectionType:
+		//A collection's size is only known once it is destructured
+		for _, elementType := range t.ElementTypes {
+			if !elementType.Accepts(other.ElementType, ctx) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (t *PatternType) acceptsParts(partTypes []Type, ctx *Context) bool {
+	if len(partTypes) != len(t.ElementTypes) {
+		return false
+	}
+	for i, elementType := range t.ElementTypes {
+		if !elementType.Accepts(partTypes[i], ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func bindPattern(ctx *Context, pattern parser.Pattern, value *Value, mutable bool, restricted bool) {
+	switch t := pattern.(type) {
+	case parser.IdentifierPattern:
+		if t.Identifier == "_" {
+			return
+		}
+		variableType := value.Type
+		if t.Type != nil {
+			variableType = FromASTType(t.Type, ctx)
+			if !variableType.Accepts(value.Type, ctx) {
+				panic("Cannot use value of type " + value.Type.Name() + " in place of " + variableType.Name() + " for variable " + t.Identifier)
+			}
+		}
+		ctx.DefineVariable(&Variable{
+			Name:       t.Identifier,
+			Mutable:    mutable,
+			Type:       variableType,
+			Value:      value,
+			Restricted: restricted,
+			Namespace:  ctx.namespace,
+		})
+
+	case parser.TuplePattern:
+		elements := destructure(ctx, value)
+		if len(elements) != len(t.Elements) {
+			panic(fmt.Sprintf("Cannot destructure %s with %d elements into a pattern with %d elements", value.Type.Name(), len(elements), len(t.Elements)))
+		}
+		for i, element := range t.Elements {
+			bindPattern(ctx, element, elements[i], mutable, restricted)
+		}
+	default:
+		panic("Unknown pattern " + reflect.TypeOf(pattern).Name())
+	}
+}
+
+//destructure splits a value into its parts, in the order that a pattern binds them
+func destructure(ctx *Context, value *Value) []*Value {
+	switch t := value.Value.(type) {
+	case *Tuple:
+		return t.Elements
+	case *Collection:
+		return t.Elements()
+	case string:
+		return charsOf(t).Elements()
+	case *Instance:
+		//Structs are destructured in the order their properties are declared
+		elements := make([]*Value, len(t.Type.Properties))
+		for i, property := range t.Type.Properties {
+			t.Type.checkAccess(property, ctx)
+			elements[i] = t.Values[property.Name]
+		}
+		return elements
+	}
+	panic("Cannot destructure value of type " + value.Type.Name())
+}
@@ -0,0 +1,48 @@
+package interpreter
+
+var ErrorType = NewEmptyType("Error")
+
+//An Error is a value describing something that went wrong, such as an invalid regex or a missing file.
+//Natives that can fail for reasons outside of the script's control return an Error rather than panicking,
+//so that scripts can handle the failure by checking `is Error`.
+type Error struct {
+	Message string
+}
+
+func ErrorValue(message string) *Value {
+	return NewValue(ErrorType, &Error{Message: message})
+}
+
+//orError is the return type of a native that returns either a value of the given type or an Error
+func orError(t Type) Type {
+	return &UnionType{
+		a: t,
+		b: ErrorType,
+	}
+}
+
+func (e *Error) String() string {
+	return "Error: " + e.Message
+}
+
+func (e *Error) Equals(other *Value) bool {
+	otherError, isError := other.Value.(*Error)
+	return isError && e.Message == otherError.Message
+}
+
+func InitErrors(ctx *Context) {
+	define(ctx, "error", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "message",
+					Type: StringType,
+				},
+			},
+			ReturnType: ErrorType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			return NonReturningValue(ErrorValue(stringParameter(ctx, 0)))
+		}),
+	})
+}
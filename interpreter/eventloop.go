@@ -0,0 +1,405 @@
+package interpreter
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+//A Clock tells the EventLoop what the time is, and how to wait for a timer
+type Clock interface {
+	Now() time.Time
+	//After returns a channel that receives the time once duration has passed, and a function to call once the time is no longer needed,
+	//eg because a select chose another case
+	After(duration time.Duration) (<-chan time.Time, func())
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(duration time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(duration)
+	return timer.C, func() {
+		timer.Stop()
+	}
+}
+
+//A VirtualClock only moves forward when something waiting for it receives the time, and then jumps straight to it.
+//The earliest time that is being waited for is given out first, so scripts using it run their timers in the same order as with the real time,
+//but without waiting, so tests using it are fast and deterministic. Waiting for a time that is never received, eg because a select chose
+//another case, doesn't move the clock.
+type VirtualClock struct {
+	mutex    sync.Mutex
+	given    *sync.Cond //Broadcast when the time being given out has been received or given up on
+	now      time.Time
+	waiting  []*virtualWait
+	offering bool //Set while the earliest time is being given out, so that Now waits to see whether it is received
+}
+
+//A virtualWait is a call to VirtualClock.After that hasn't received its time yet
+type virtualWait struct {
+	due      time.Time
+	fired    chan time.Time
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	clock := &VirtualClock{now: start}
+	clock.given = sync.NewCond(&clock.mutex)
+	return clock
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.offering {
+		c.given.Wait()
+	}
+	return c.now
+}
+
+func (c *VirtualClock) After(duration time.Duration) (<-chan time.Time, func()) {
+	c.mutex.Lock()
+	wait := &virtualWait{
+		due:     c.now.Add(duration),
+		fired:   make(chan time.Time),
+		stopped: make(chan struct{}),
+	}
+	c.waiting = append(c.waiting, wait)
+	c.mutex.Unlock()
+	go c.giveNext()
+	return wait.fired, func() {
+		wait.stopOnce.Do(func() {
+			close(wait.stopped)
+		})
+	}
+}
+
+//giveNext gives the earliest time that is being waited for to whatever is waiting for it, and moves the clock to it once it has been received.
+//Every call to After starts one, so every wait is either given its time or stopped
+func (c *VirtualClock) giveNext() {
+	c.mutex.Lock()
+	for c.offering {
+		c.given.Wait()
+	}
+	for len(c.waiting) != 0 {
+		earliest := 0
+		for i, wait := range c.waiting {
+			if wait.due.Before(c.waiting[earliest].due) {
+				earliest = i
+			}
+		}
+		wait := c.waiting[earliest]
+		c.waiting = append(c.waiting[:earliest], c.waiting[earliest+1:]...)
+
+		c.offering = true
+		c.mutex.Unlock()
+		received := false
+		select {
+		case wait.fired <- wait.due:
+			received = true
+		case <-wait.stopped:
+		}
+		c.mutex.Lock()
+		c.offering = false
+		c.given.Broadcast()
+		if received {
+			if wait.due.After(c.now) {
+				c.now = wait.due
+			}
+			break
+		}
+	}
+	c.mutex.Unlock()
+}
+
+func (c *VirtualClock) Advance(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.offering {
+		c.given.Wait()
+	}
+	c.now = c.now.Add(duration)
+}
+
+var TimerType = NewEmptyType("Timer")
+
+//A Timer is a callback waiting to be run by an EventLoop, created by setTimeout or setInterval
+type Timer struct {
+	due       time.Time
+	interval  time.Duration //0 unless the timer repeats
+	callback  func()
+	sequence  int64 //Timers that are due at the same time run in the order they were created
+	index     int   //The position of the timer in the queue, or -1 once it has been removed
+	cancelled bool
+}
+
+func (t *Timer) String() string {
+	return "Timer"
+}
+
+type timerQueue []*Timer
+
+func (q timerQueue) Len() int {
+	return len(q)
+}
+
+func (q timerQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].sequence < q[j].sequence
+	}
+	return q[i].due.Before(q[j].due)
+}
+
+func (q timerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *timerQueue) Push(x interface{}) {
+	timer := x.(*Timer)
+	timer.index = len(*q)
+	*q = append(*q, timer)
+}
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	timer := old[len(old)-1]
+	timer.index = -1
+	*q = old[:len(old)-1]
+	return timer
+}
+
+//An EventLoop runs the timers of an Interpreter, and keeps track of the tasks that it has spawned.
+//Callbacks always run on the goroutine running the loop, one at a time, so they never run at the same time as the rest of the script.
+//Timers can be created and cancelled from any goroutine.
+type EventLoop struct {
+	mutex    sync.Mutex
+	clock    Clock
+	timers   timerQueue
+	sequence int64
+	tasks    int           //Tasks that have been spawned and haven't finished
+	failed   []*Task       //Tasks that have failed since the run started, which are checked once it finishes
+	wake     chan struct{} //Signalled when the timers or tasks change, so that Run can stop waiting for a timer that is no longer the next one
+	flush    func()        //Called on every turn of the loop, so that output written by timers is seen straight away
+}
+
+func NewEventLoop(clock Clock, flush func()) *EventLoop {
+	return &EventLoop{
+		clock: clock,
+		wake:  make(chan struct{}, 1),
+		flush: flush,
+	}
+}
+
+//Schedule runs callback after delay, and then every interval if interval isn't 0, until the Timer is cancelled
+func (l *EventLoop) Schedule(delay time.Duration, interval time.Duration, callback func()) *Timer {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sequence++
+	timer := &Timer{
+		due:      l.clock.Now().Add(delay),
+		interval: interval,
+		callback: callback,
+		sequence: l.sequence,
+	}
+	heap.Push(&l.timers, timer)
+	l.signal()
+	return timer
+}
+
+//Cancel stops a timer from running again. Cancelling a timer that has already finished does nothing
+func (l *EventLoop) Cancel(timer *Timer) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	timer.cancelled = true
+	if timer.index >= 0 && timer.index < len(l.timers) && l.timers[timer.index] == timer {
+		heap.Remove(&l.timers, timer.index)
+	}
+	l.signal()
+}
+
+func (l *EventLoop) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+//Pending is how many timers are waiting to run, and tasks are still running
+func (l *EventLoop) Pending() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.timers) + l.tasks
+}
+
+func (l *EventLoop) startTask() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tasks++
+}
+
+func (l *EventLoop) finishTask() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tasks--
+	l.signal()
+}
+
+//waitForTasks waits until every task has finished, letting them run in the meantime
+func (l *EventLoop) waitForTasks(global *Global) {
+	for l.runningTasks() != 0 {
+		global.blocking(func() {
+			<-l.wake
+		})
+	}
+}
+
+func (l *EventLoop) taskFailed(task *Task) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.failed = append(l.failed, task)
+}
+
+//failedTasks gives the tasks that have failed since it was last called
+func (l *EventLoop) failedTasks() []*Task {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	failed := l.failed
+	l.failed = nil
+	return failed
+}
+
+func (l *EventLoop) runningTasks() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.tasks
+}
+
+//Clear cancels every timer, for example once the script has exited
+func (l *EventLoop) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, timer := range l.timers {
+		timer.cancelled = true
+		timer.index = -1
+	}
+	l.timers = nil
+}
+
+//Run runs timers as they become due, until there are none left and every task has finished.
+//Tasks can run while it is waiting. If the context.Context is cancelled, Run panics with a LimitError
+func (l *EventLoop) Run(goContext context.Context, global *Global) {
+	for {
+		l.flush()
+		l.mutex.Lock()
+		if len(l.timers) == 0 && l.tasks == 0 {
+			l.mutex.Unlock()
+			return
+		}
+		var wait time.Duration
+		var next *Timer
+		if len(l.timers) != 0 {
+			next = l.timers[0]
+			wait = next.due.Sub(l.clock.Now())
+		}
+		if next == nil || wait > 0 {
+			l.mutex.Unlock()
+			var due <-chan time.Time //Only tasks can wake the loop if there aren't any timers
+			stop := func() {}
+			if next != nil {
+				due, stop = l.clock.After(wait)
+			}
+			global.blocking(func() {
+				defer stop()
+				select {
+				case <-due:
+				case <-l.wake:
+				case <-goContext.Done():
+					panic(&LimitError{Err: goContext.Err()})
+				}
+			})
+			continue //The next timer may have changed while waiting
+		}
+		heap.Pop(&l.timers)
+		l.mutex.Unlock()
+
+		next.callback()
+
+		if next.interval > 0 {
+			l.mutex.Lock()
+			if !next.cancelled {
+				next.due = next.due.Add(next.interval)
+				heap.Push(&l.timers, next)
+			}
+			l.mutex.Unlock()
+		}
+	}
+}
+
+//InitTimers defines setTimeout and setInterval, which run their callbacks on the given loop once the rest of the script has finished
+func InitTimers(ctx *Context, loop *EventLoop) {
+	root := ctx //Callbacks run after the scope that created them has gone, so they are called from the global scope
+	timerFunction := func(name string, repeat bool) {
+		defineImpure(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: []Parameter{
+					{
+						Name: "callback",
+						Type: functionTypeOf(AnyType),
+					},
+					{
+						Name:     "ms",
+						Type:     IntType,
+						Position: 1,
+					},
+				},
+				ReturnType: TimerType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				callback := ctx.FindParameter(0).Value.(*Function)
+				delay := time.Duration(ctx.FindParameter(1).Value.(int64)) * time.Millisecond
+				if delay < 0 {
+					panic("Timer delay must not be negative")
+				}
+				interval := time.Duration(0)
+				if repeat {
+					if delay == 0 {
+						panic("Interval must be greater than 0")
+					}
+					interval = delay
+				}
+				timer := loop.Schedule(delay, interval, func() {
+					callback.Exec(root, []*Value{})
+				})
+				return NonReturningValue(NewValue(TimerType, timer))
+			}),
+		})
+	}
+	//setTimeout runs the callback once, after at least ms milliseconds
+	timerFunction("setTimeout", false)
+	//setInterval runs the callback every ms milliseconds until it is cancelled
+	timerFunction("setInterval", true)
+
+	defineImpure(ctx, "cancel", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: TimerType,
+				},
+			},
+			ReturnType: UnitType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			loop.Cancel(ctx.FindParameter(0).Value.(*Timer))
+			return NonReturningValue(UnitValue())
+		}),
+	})
+}
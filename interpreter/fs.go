@@ -0,0 +1,249 @@
+package interpreter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+//Every path given to elara/fs goes through fsPath, so that relative paths are resolved and cleaned in the same way everywhere.
+//Failures such as missing files or missing permissions are returned as Errors rather than panicking.
+//The interpreter's Permissions are different: a script that isn't allowed to read or write a path is stopped with a PermissionError.
+
+//fileInfoType describes a file or directory, as returned by stat
+var fileInfoType = NewNativeStructType("FileInfo", "elara/fs", []Property{
+	{Name: "name", Type: StringType},
+	{Name: "path", Type: StringType},
+	{Name: "size", Type: IntType},
+	{Name: "isDirectory", Type: BooleanType},
+	{Name: "modified", Type: IntType}, //Milliseconds since the Unix epoch
+})
+
+//fsPath gets a path parameter as an absolute, cleaned path
+func fsPath(ctx *Context, position uint) string {
+	path, err := filepath.Abs(stringParameter(ctx, position))
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+//readPath gets a path parameter in the same way as fsPath, checking that the script is allowed to read it
+func readPath(ctx *Context, position uint) string {
+	path := fsPath(ctx, position)
+	ctx.permissions.checkRead(path)
+	return path
+}
+
+//writePath gets a path parameter in the same way as fsPath, checking that the script is allowed to write to it
+func writePath(ctx *Context, position uint) string {
+	path := fsPath(ctx, position)
+	ctx.permissions.checkWrite(path)
+	return path
+}
+
+func fileInfoValue(path string, info os.FileInfo) *Value {
+	return NewInstanceValue(fileInfoType,
+		StringValue(info.Name()),
+		StringValue(path),
+		IntValue(info.Size()),
+		BooleanValue(info.IsDir()),
+		IntValue(info.ModTime().UnixNano()/1e6),
+	)
+}
+
+//bytesValue converts bytes into an [Int], with every element between 0 and 255
+func bytesValue(bytes []byte) *Value {
+	elements := make([]*Value, len(bytes))
+	for i, b := range bytes {
+		elements[i] = IntValue(int64(b))
+	}
+	return CollectionValue(IntType, elements)
+}
+
+func bytesParameter(ctx *Context, position uint) []byte {
+	elements := collectionParameter(ctx, position).Elements()
+	bytes := make([]byte, len(elements))
+	for i, element := range elements {
+		b := element.Value.(int64)
+		if b < 0 || b > 255 {
+			panic("Byte " + strconv.FormatInt(b, 10) + " at index " + strconv.Itoa(i) + " is not between 0 and 255")
+		}
+		bytes[i] = byte(b)
+	}
+	return bytes
+}
+
+func InitFS(ctx *Context) {
+	ctx.types[fileInfoType.TypeName] = fileInfoType
+
+	fsFunction := func(name string, returnType Type, parameterTypes []Type, body func(ctx *Context) (*Value, error)) {
+		parameters := make([]Parameter, len(parameterTypes))
+		for i, parameterType := range parameterTypes {
+			parameters[i] = Parameter{
+				Name:     "arg" + strconv.Itoa(i),
+				Type:     parameterType,
+				Position: uint(i),
+			}
+		}
+		defineImpure(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: parameters,
+				ReturnType: orError(returnType),
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				value, err := body(ctx)
+				if err != nil {
+					return NonReturningValue(ErrorValue(err.Error()))
+				}
+				return NonReturningValue(value)
+			}),
+		})
+	}
+
+	fsFunction("readText", StringType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		content, err := ioutil.ReadFile(readPath(ctx, 0))
+		if err != nil {
+			return nil, err
+		}
+		return StringValue(string(content)), nil
+	})
+
+	fsFunction("readBytes", NewCollectionTypeOf(IntType), []Type{StringType}, func(ctx *Context) (*Value, error) {
+		content, err := ioutil.ReadFile(readPath(ctx, 0))
+		if err != nil {
+			return nil, err
+		}
+		return bytesValue(content), nil
+	})
+
+	//writeText and writeBytes create the file if it doesn't exist, and replace its contents if it does
+	fsFunction("writeText", UnitType, []Type{StringType, StringType}, func(ctx *Context) (*Value, error) {
+		return UnitValue(), ioutil.WriteFile(writePath(ctx, 0), []byte(stringParameter(ctx, 1)), 0644)
+	})
+
+	fsFunction("writeBytes", UnitType, []Type{StringType, NewCollectionTypeOf(IntType)}, func(ctx *Context) (*Value, error) {
+		return UnitValue(), ioutil.WriteFile(writePath(ctx, 0), bytesParameter(ctx, 1), 0644)
+	})
+
+	fsFunction("appendText", UnitType, []Type{StringType, StringType}, func(ctx *Context) (*Value, error) {
+		file, err := os.OpenFile(writePath(ctx, 0), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		_, err = file.WriteString(stringParameter(ctx, 1))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return UnitValue(), err
+	})
+
+	//list gives the names of the entries in a directory, sorted by name
+	fsFunction("list", NewCollectionTypeOf(StringType), []Type{StringType}, func(ctx *Context) (*Value, error) {
+		entries, err := ioutil.ReadDir(readPath(ctx, 0))
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		return stringCollectionValue(names), nil
+	})
+
+	fsFunction("stat", fileInfoType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		path := readPath(ctx, 0)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		return fileInfoValue(path, info), nil
+	})
+
+	fsFunction("exists", BooleanType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		_, err := os.Stat(readPath(ctx, 0))
+		if os.IsNotExist(err) {
+			return BooleanValue(false), nil
+		}
+		return BooleanValue(err == nil), err
+	})
+
+	//mkdir also creates any missing parent directories, and does nothing if the directory already exists
+	fsFunction("mkdir", UnitType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		return UnitValue(), os.MkdirAll(writePath(ctx, 0), 0755)
+	})
+
+	//delete only deletes directories if they are empty
+	fsFunction("delete", UnitType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		return UnitValue(), os.Remove(writePath(ctx, 0))
+	})
+
+	fsFunction("deleteAll", UnitType, []Type{StringType}, func(ctx *Context) (*Value, error) {
+		return UnitValue(), os.RemoveAll(writePath(ctx, 0))
+	})
+
+	//glob gives every path matching a pattern such as "logs/*.txt", sorted. Paths that the script isn't allowed to read are left out
+	fsFunction("glob", NewCollectionTypeOf(StringType), []Type{StringType}, func(ctx *Context) (*Value, error) {
+		matches, err := filepath.Glob(fsPath(ctx, 0)) //Resolving the pattern first makes every match absolute, like every other path from elara/fs
+		if err != nil {
+			return nil, err
+		}
+		readable := make([]string, 0, len(matches))
+		for _, match := range matches {
+			if ctx.permissions.canRead(match) {
+				readable = append(readable, match)
+			}
+		}
+		sort.Strings(readable)
+		return stringCollectionValue(readable), nil
+	})
+
+	pathFunction := func(name string, parameterCount int, body func(ctx *Context) string) {
+		parameters := make([]Parameter, parameterCount)
+		for i := range parameters {
+			parameters[i] = Parameter{
+				Name:     "arg" + strconv.Itoa(i),
+				Type:     StringType,
+				Position: uint(i),
+			}
+		}
+		define(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: parameters,
+				ReturnType: StringType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				return NonReturningValue(StringValue(body(ctx)))
+			}),
+		})
+	}
+
+	//The path functions only work on the text of a path, and don't touch the filesystem
+	pathFunction("joinPath", 2, func(ctx *Context) string {
+		return filepath.Join(stringParameter(ctx, 0), stringParameter(ctx, 1))
+	})
+
+	//normalisePath removes redundant separators and resolves . and .. without making the path absolute
+	pathFunction("normalisePath", 1, func(ctx *Context) string {
+		return filepath.Clean(stringParameter(ctx, 0))
+	})
+
+	pathFunction("absolutePath", 1, func(ctx *Context) string {
+		return fsPath(ctx, 0)
+	})
+
+	pathFunction("baseName", 1, func(ctx *Context) string {
+		return filepath.Base(stringParameter(ctx, 0))
+	})
+
+	pathFunction("dirName", 1, func(ctx *Context) string {
+		return filepath.Dir(stringParameter(ctx, 0))
+	})
+
+	//extension includes the dot, eg ".txt", or is empty if the path doesn't have one
+	pathFunction("extension", 1, func(ctx *Context) string {
+		return filepath.Ext(stringParameter(ctx, 0))
+	})
+}
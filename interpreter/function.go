@@ -11,6 +11,7 @@ type Function struct {
 	Body      Command
 	name      *string
 	context   *Context
+	impure    bool //Set for natives with side effects, such as I/O, which parallel functions can't call
 }
 
 func (f *Function) String() string {
@@ -22,11 +23,21 @@ func (f *Function) String() string {
 }
 
 func (f *Function) Exec(ctx *Context, parameters []*Value) (val *Value) {
+	return f.ExecWithTypeArguments(ctx, nil, parameters)
+}
+
+//ExecWithTypeArguments invokes the function with explicit type arguments for its type parameters.
+//Any type parameters without an explicit argument are inferred from the parameters.
+func (f *Function) ExecWithTypeArguments(ctx *Context, typeArguments []Type, parameters []*Value) (val *Value) {
 	context := ctx
 	if f.context != nil {
 		//The cached context has highest priority for things like variables, but we set the parent to ensure that we can correctly inherit things like imports
 		context = f.context.Clone()
 		context.parent = ctx
+		context.lazyChain = ctx.lazyChain
+		context.limits = ctx.limits
+		context.permissions = ctx.permissions
+		context.parallel = ctx.parallel
 	}
 	if len(parameters) != len(f.Signature.Parameters) {
 		panic(fmt.Sprintf("Illegal number of arguments for function %s. Expected %d, received %d", util.NillableStringify(f.name, "<anonymous>"), len(f.Signature.Parameters), len(parameters)))
@@ -38,10 +49,29 @@ func (f *Function) Exec(ctx *Context, parameters []*Value) (val *Value) {
 	} else {
 		name = *f.name
 	}
+	if f.impure && ctx.parallel {
+		panic(name + " has side effects, so it can't be called from a parallel function")
+	}
 	scope := context.EnterScope(name, f, uint(len(f.Signature.Parameters)))
+	limits := scope.limits
+	defer limits.exitCall() //Deferred so that the depth is still right if the function panics and the panic is recovered, eg by Runtime.Call
+	limits.enterCall()
+
+	signature := &f.Signature
+	if len(signature.TypeParameters) != 0 {
+		bindings := signature.bindTypeArguments(ctx, typeArguments, parameters)
+		bound := signature.substitute(bindings, ctx)
+		signature = &bound
+		//Type parameters are visible by name inside the function, eg so that the body can use `is T`
+		for parameter, binding := range bindings {
+			scope.types[parameter.name] = binding
+		}
+	} else if len(typeArguments) != 0 {
+		panic("Function " + name + " is not generic, but was given type arguments")
+	}
 
 	for i, paramValue := range parameters {
-		expectedParameter := f.Signature.Parameters[i]
+		expectedParameter := signature.Parameters[i]
 
 		if !expectedParameter.Type.Accepts(paramValue.Type, ctx) {
 			panic(fmt.Sprintf("Expected %s for parameter %s and got %s (%s)", expectedParameter.Type.Name(), expectedParameter.Name, paramValue.String(), paramValue.Type.Name()))
@@ -53,24 +83,27 @@ func (f *Function) Exec(ctx *Context, parameters []*Value) (val *Value) {
 		scope.DefineParameter(expectedParameter.Position, paramValue.Copy()) //Passing by value
 	}
 
-	value := f.Body.Exec(scope).Value //Can't unwrap because it might have returned from the function
-	scope.Cleanup()                   //Exit out of the scope
+	result := f.Body.Exec(scope)
+	result.checkNoJump()
+	value := result.Value //Can't unwrap because it might have returned from the function
+	scope.Cleanup() //Exit out of the scope
 	if value == nil {
 		value = UnitValue()
 	}
-	if !f.Signature.ReturnType.Accepts(value.Type, ctx) {
+	if !signature.ReturnType.Accepts(value.Type, ctx) {
 		name := "<anonymous>"
 		if f.name != nil {
 			name = *f.name
 		}
-		panic(fmt.Sprintf("Function '%s' did not return value of type %s, instead was %s", name, f.Signature.ReturnType.Name(), value.Type.Name()))
+		panic(fmt.Sprintf("Function '%s' did not return value of type %s, instead was %s", name, signature.ReturnType.Name(), value.Type.Name()))
 	}
 	return value
 }
 
 type Signature struct {
-	Parameters []Parameter
-	ReturnType Type
+	TypeParameters []*TypeParameter //Empty unless the function is generic
+	Parameters     []Parameter
+	ReturnType     Type
 }
 
 func (s *Signature) String() string {
@@ -78,7 +111,7 @@ func (s *Signature) String() string {
 	for i := range s.Parameters {
 		paramNames[i] = s.Parameters[i].Type.Name()
 	}
-	return fmt.Sprintf("(%s) => %s", strings.Join(paramNames, ", "), s.ReturnType.Name())
+	return fmt.Sprintf("%s(%s) => %s", typeParametersString(s.TypeParameters), strings.Join(paramNames, ", "), s.ReturnType.Name())
 }
 
 func (s *Signature) Accepts(other *Signature, ctx *Context, compareReturnTypes bool) bool {
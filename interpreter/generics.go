@@ -0,0 +1,310 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/ElaraLang/elara/parser"
+	"strings"
+)
+
+//A TypeParameter is a generic type variable, such as the T in <T> let identity = (T value) => T { value }
+//Until it is bound to a concrete type at a call site, it behaves like its bound.
+type TypeParameter struct {
+	name  string
+	Bound Type
+}
+
+func NewTypeParameter(name string, bound Type) *TypeParameter {
+	if bound == nil {
+		bound = AnyType
+	}
+	return &TypeParameter{
+		name:  name,
+		Bound: bound,
+	}
+}
+
+func (t *TypeParameter) Name() string {
+	return t.name
+}
+
+func (t *TypeParameter) Accepts(otherType Type, ctx *Context) bool {
+	if otherType == t {
+		return true
+	}
+	asParameter, isParameter := otherType.(*TypeParameter)
+	if isParameter {
+		return t.Bound.Accepts(asParameter.Bound, ctx)
+	}
+	return t.Bound.Accepts(otherType, ctx)
+}
+
+//checkBound panics if the given type argument does not satisfy the type parameter's bound
+func (t *TypeParameter) checkBound(argument Type, ctx *Context) {
+	if !t.Bound.Accepts(argument, ctx) {
+		panic("Type " + argument.Name() + " does not satisfy the bound " + t.Bound.Name() + " of type parameter " + t.name)
+	}
+}
+
+//typeParametersString formats type parameters in the same way that they are declared, eg <T, U: Int>
+func typeParametersString(parameters []*TypeParameter) string {
+	if len(parameters) == 0 {
+		return ""
+	}
+	names := make([]string, len(parameters))
+	for i, parameter := range parameters {
+		names[i] = parameter.name
+		if parameter.Bound != AnyType {
+			names[i] += ": " + parameter.Bound.Name()
+		}
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
+
+//resolveTypeParameters converts the generic contracts from a GenerifiedStmt into type parameters.
+//The returned context has the type parameters defined as types, so that it can be used to resolve types that refer to them.
+//It must be cleaned up once it is no longer needed.
+func resolveTypeParameters(contracts []parser.GenericContract, ctx *Context) ([]*TypeParameter, *Context) {
+	typeContext := ctx.EnterScope("<generic>", ctx.function, 0)
+	parameters := make([]*TypeParameter, len(contracts))
+	for i, contract := range contracts {
+		var bound Type
+		if contract.Contract != nil {
+			bound = FromASTType(contract.Contract, typeContext) //Bounds may refer to earlier type parameters
+		}
+		parameter := NewTypeParameter(contract.Identifier, bound)
+		typeContext.types[contract.Identifier] = parameter
+		parameters[i] = parameter
+	}
+	return parameters, typeContext
+}
+
+//bindTypeArguments works out the concrete type of each of the signature's type parameters for an invocation.
+//Explicit type arguments are used first, then any remaining type parameters are inferred from the argument values.
+//Type parameters that cannot be inferred are bound to their bound.
+func (s *Signature) bindTypeArguments(ctx *Context, typeArguments []Type, arguments []*Value) map[*TypeParameter]Type {
+	if len(typeArguments) > len(s.TypeParameters) {
+		panic(fmt.Sprintf("Too many type arguments for %s. Expected at most %d, received %d", s.String(), len(s.TypeParameters), len(typeArguments)))
+	}
+	bindings := make(map[*TypeParameter]Type, len(s.TypeParameters))
+	explicit := make(map[*TypeParameter]bool, len(typeArguments))
+	for i, argument := range typeArguments {
+		bindings[s.TypeParameters[i]] = argument
+		explicit[s.TypeParameters[i]] = true
+	}
+
+	for i, argument := range arguments {
+		inferTypeArguments(s.Parameters[i].Type, argument.Type, bindings, explicit, ctx)
+	}
+
+	for _, parameter := range s.TypeParameters {
+		binding, bound := bindings[parameter]
+		if !bound {
+			bindings[parameter] = parameter.Bound
+			continue
+		}
+		parameter.checkBound(binding, ctx)
+	}
+	return bindings
+}
+
+//inferTypeArguments structurally matches a parameter's declared type against the type of the value passed for it,
+//recording a binding for each type parameter that it finds.
+func inferTypeArguments(parameterType Type, argumentType Type, bindings map[*TypeParameter]Type, explicit map[*TypeParameter]bool, ctx *Context) {
+	switch t := parameterType.(type) {
+	case *TypeParameter:
+		existing, present := bindings[t]
+		if !present {
+			bindings[t] = argumentType
+			return
+		}
+		if explicit[t] || existing.Accepts(argumentType, ctx) {
+			return //Explicit arguments always win, and mismatches will be reported when the parameter is checked
+		}
+		if argumentType.Accepts(existing, ctx) {
+			bindings[t] = argumentType //Widen to the more general type
+			return
+		}
+		panic("Conflicting types for type parameter " + t.name + ": " + existing.Name() + " and " + argumentType.Name())
+
+	case *CollectionType:
+		asCollection, isCollection := argumentType.(*CollectionType)
+		if isCollection {
+			inferTypeArguments(t.ElementType, asCollection.ElementType, bindings, explicit, ctx)
+		}
+	case *TupleType:
+		asTuple, isTuple := argumentType.(*TupleType)
+		if isTuple && len(asTuple.ElementTypes) == len(t.ElementTypes) {
+			for i, elementType := range t.ElementTypes {
+				inferTypeArguments(elementType, asTuple.ElementTypes[i], bindings, explicit, ctx)
+			}
+		}
+	case *PatternType:
+		switch argument := argumentType.(type) {
+		case *TupleType:
+			if len(argument.ElementTypes) == len(t.ElementTypes) {
+				for i, elementType := range t.ElementTypes {
+					inferTypeArguments(elementType, argument.ElementTypes[i], bindings, explicit, ctx)
+				}
+			}
+		case *CollectionType:
+			for _, elementType := range t.ElementTypes {
+				inferTypeArguments(elementType, argument.ElementType, bindings, explicit, ctx)
+			}
+		}
+	case *SetType:
+		asSet, isSet := argumentType.(*SetType)
+		if isSet {
+			inferTypeArguments(t.ElementType, asSet.ElementType, bindings, explicit, ctx)
+		}
+	case *MapType:
+		asMap, isMap := argumentType.(*MapType)
+		if isMap {
+			inferTypeArguments(t.KeyType, asMap.KeyType, bindings, explicit, ctx)
+			inferTypeArguments(t.ValueType, asMap.ValueType, bindings, explicit, ctx)
+		}
+	case *FunctionType:
+		asFunction, isFunction := argumentType.(*FunctionType)
+		if isFunction && len(asFunction.Signature.Parameters) == len(t.Signature.Parameters) {
+			for i, parameter := range t.Signature.Parameters {
+				inferTypeArguments(parameter.Type, asFunction.Signature.Parameters[i].Type, bindings, explicit, ctx)
+			}
+			if asFunction.Signature.ReturnType != AnyType { //Function literals without a declared return type tell us nothing
+				inferTypeArguments(t.Signature.ReturnType, asFunction.Signature.ReturnType, bindings, explicit, ctx)
+			}
+		}
+	case *StructType:
+		asStruct, isStruct := argumentType.(*StructType)
+		if isStruct && t.generic != nil && asStruct.generic == t.generic {
+			for i, typeArgument := range t.TypeArguments {
+				inferTypeArguments(typeArgument, asStruct.TypeArguments[i], bindings, explicit, ctx)
+			}
+		}
+	}
+}
+
+//substituteType replaces any bound type parameters in a type with the types they are bound to
+func substituteType(t Type, bindings map[*TypeParameter]Type, ctx *Context) Type {
+	if len(bindings) == 0 {
+		return t
+	}
+	switch t := t.(type) {
+	case *TypeParameter:
+		binding, present := bindings[t]
+		if present {
+			return binding
+		}
+		return t
+	case *CollectionType:
+		return &CollectionType{
+			ElementType: substituteType(t.ElementType, bindings, ctx),
+		}
+	case *SetType:
+		return NewSetTypeOf(substituteType(t.ElementType, bindings, ctx))
+	case *TupleType:
+		elementTypes := make([]Type, len(t.ElementTypes))
+		for i, elementType := range t.ElementTypes {
+			elementTypes[i] = substituteType(elementType, bindings, ctx)
+		}
+		return &TupleType{
+			ElementTypes: elementTypes,
+		}
+	case *PatternType:
+		elementTypes := make([]Type, len(t.ElementTypes))
+		for i, elementType := range t.ElementTypes {
+			elementTypes[i] = substituteType(elementType, bindings, ctx)
+		}
+		return &PatternType{ElementTypes: elementTypes}
+	case *MapType:
+		return &MapType{
+			KeyType:   substituteType(t.KeyType, bindings, ctx),
+			ValueType: substituteType(t.ValueType, bindings, ctx),
+		}
+	case *FunctionType:
+		return NewSignatureFunctionType(t.Signature.substitute(bindings, ctx))
+	case *UnionType:
+		return &UnionType{
+			a: substituteType(t.a, bindings, ctx),
+			b: substituteType(t.b, bindings, ctx),
+		}
+	case *IntersectionType:
+		return &IntersectionType{
+			a: substituteType(t.a, bindings, ctx),
+			b: substituteType(t.b, bindings, ctx),
+		}
+	case *StructType:
+		if t.generic == nil {
+			return t
+		}
+		arguments := make([]Type, len(t.TypeArguments))
+		for i, argument := range t.TypeArguments {
+			arguments[i] = substituteType(argument, bindings, ctx)
+		}
+		return t.generic.Specialise(arguments, ctx)
+	}
+	return t
+}
+
+func (s *Signature) substitute(bindings map[*TypeParameter]Type, ctx *Context) Signature {
+	parameters := make([]Parameter, len(s.Parameters))
+	for i, parameter := range s.Parameters {
+		parameter.Type = substituteType(parameter.Type, bindings, ctx)
+		parameters[i] = parameter
+	}
+	//Type parameters that have been bound are no longer generic
+	typeParameters := make([]*TypeParameter, 0)
+	for _, parameter := range s.TypeParameters {
+		if _, bound := bindings[parameter]; !bound {
+			typeParameters = append(typeParameters, parameter)
+		}
+	}
+	return Signature{
+		TypeParameters: typeParameters,
+		Parameters:     parameters,
+		ReturnType:     substituteType(s.ReturnType, bindings, ctx),
+	}
+}
+
+//Specialise returns a copy of a generic struct type with its type parameters replaced by the given type arguments, eg Box<Int> from Box.
+//Specialisations are cached, so the same type arguments will always give the same type.
+func (t *StructType) Specialise(typeArguments []Type, ctx *Context) *StructType {
+	if len(t.TypeParameters) == 0 {
+		panic("Struct " + t.TypeName + " is not generic")
+	}
+	if len(typeArguments) != len(t.TypeParameters) {
+		panic(fmt.Sprintf("Struct %s expects %d type arguments, received %d", t.TypeName, len(t.TypeParameters), len(typeArguments)))
+	}
+	names := make([]string, len(typeArguments))
+	bindings := make(map[*TypeParameter]Type, len(typeArguments))
+	for i, argument := range typeArguments {
+		t.TypeParameters[i].checkBound(argument, ctx)
+		names[i] = argument.Name()
+		bindings[t.TypeParameters[i]] = argument
+	}
+	name := t.TypeName + "<" + strings.Join(names, ", ") + ">"
+
+	t.specialisationLock.Lock()
+	defer t.specialisationLock.Unlock()
+	existing, present := t.specialisations[name]
+	if present {
+		return existing
+	}
+
+	properties := make([]Property, len(t.Properties))
+	for i, property := range t.Properties {
+		property.Type = substituteType(property.Type, bindings, ctx)
+		properties[i] = property
+	}
+	specialised := &StructType{
+		TypeName:          name,
+		Properties:        properties,
+		propertyPositions: t.propertyPositions,
+		namespace:         t.namespace,
+		generic:           t,
+		TypeArguments:     typeArguments,
+	}
+	if t.specialisations == nil {
+		t.specialisations = map[string]*StructType{}
+	}
+	t.specialisations[name] = specialised
+	return specialised
+}
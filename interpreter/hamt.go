@@ -0,0 +1,197 @@
+package interpreter
+
+import "math/bits"
+
+//hamtNode is a node in a hash array mapped trie, the persistent structure behind maps.
+//Each level of the trie uses 5 bits of a key's hash code to pick one of up to 32 slots,
+//and only the slots that are in use are stored, with the bitmap recording which ones they are.
+//Like vectors, updates copy the path to the changed slot and share everything else.
+type hamtNode struct {
+	bitmap uint32
+	slots  []hamtSlot
+}
+
+//A slot either holds a child node, or the entries whose keys have the given hash code
+type hamtSlot struct {
+	node    *hamtNode
+	hash    uint64
+	entries []*Entry
+}
+
+const hamtBits = 5
+const hamtMask = 1<<hamtBits - 1
+
+var emptyHamt = &hamtNode{}
+
+func hamtPosition(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & hamtMask)
+}
+
+//index finds the position in the slots of the slot for a bit
+func (n *hamtNode) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) find(ctx *Context, key *Value, hash uint64, shift uint) *Entry {
+	bit := hamtPosition(hash, shift)
+	if n.bitmap&bit == 0 {
+		return nil
+	}
+	slot := n.slots[n.index(bit)]
+	if slot.node != nil {
+		return slot.node.find(ctx, key, hash, shift+hamtBits)
+	}
+	if slot.hash != hash {
+		return nil
+	}
+	for _, entry := range slot.entries {
+		if keysEqual(ctx, entry.Key, key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+//put returns a node with the entry added, replacing any entry with an equal key
+func (n *hamtNode) put(ctx *Context, entry *Entry, shift uint) *hamtNode {
+	bit := hamtPosition(entry.hash, shift)
+	index := n.index(bit)
+	if n.bitmap&bit == 0 {
+		slots := make([]hamtSlot, len(n.slots)+1)
+		copy(slots, n.slots[:index])
+		slots[index] = hamtSlot{
+			hash:    entry.hash,
+			entries: []*Entry{entry},
+		}
+		copy(slots[index+1:], n.slots[index:])
+		return &hamtNode{
+			bitmap: n.bitmap | bit,
+			slots:  slots,
+		}
+	}
+
+	slot := n.slots[index]
+	var newSlot hamtSlot
+	switch {
+	case slot.node != nil:
+		newSlot = hamtSlot{node: slot.node.put(ctx, entry, shift+hamtBits)}
+	case slot.hash == entry.hash:
+		entries := make([]*Entry, len(slot.entries), len(slot.entries)+1)
+		copy(entries, slot.entries)
+		replaced := false
+		for i, existing := range entries {
+			if keysEqual(ctx, existing.Key, entry.Key) {
+				entries[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			entries = append(entries, entry) //A hash collision
+		}
+		newSlot = hamtSlot{
+			hash:    slot.hash,
+			entries: entries,
+		}
+	default:
+		//Keys with different hash codes share this slot, so they need to be split up in another level
+		child := &hamtNode{
+			bitmap: hamtPosition(slot.hash, shift+hamtBits),
+			slots:  []hamtSlot{slot},
+		}
+		newSlot = hamtSlot{node: child.put(ctx, entry, shift+hamtBits)}
+	}
+	slots := make([]hamtSlot, len(n.slots))
+	copy(slots, n.slots)
+	slots[index] = newSlot
+	return &hamtNode{
+		bitmap: n.bitmap,
+		slots:  slots,
+	}
+}
+
+//remove returns a node without the entry for the key, and the entry that was removed.
+//If there was no such entry, the same node is returned along with nil
+func (n *hamtNode) remove(ctx *Context, key *Value, hash uint64, shift uint) (*hamtNode, *Entry) {
+	bit := hamtPosition(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, nil
+	}
+	index := n.index(bit)
+	slot := n.slots[index]
+	var newSlot hamtSlot
+	var removed *Entry
+	if slot.node != nil {
+		var child *hamtNode
+		child, removed = slot.node.remove(ctx, key, hash, shift+hamtBits)
+		if removed == nil {
+			return n, nil
+		}
+		switch {
+		case len(child.slots) == 0:
+			return n.withoutSlot(bit, index), removed
+		case len(child.slots) == 1 && child.slots[0].node == nil:
+			newSlot = child.slots[0] //Move a lone set of entries back up, so that the trie doesn't stay deeper than it needs to
+		default:
+			newSlot = hamtSlot{node: child}
+		}
+	} else {
+		if slot.hash != hash {
+			return n, nil
+		}
+		position := -1
+		for i, entry := range slot.entries {
+			if keysEqual(ctx, entry.Key, key) {
+				position = i
+				break
+			}
+		}
+		if position == -1 {
+			return n, nil
+		}
+		removed = slot.entries[position]
+		if len(slot.entries) == 1 {
+			return n.withoutSlot(bit, index), removed
+		}
+		entries := make([]*Entry, 0, len(slot.entries)-1)
+		entries = append(entries, slot.entries[:position]...)
+		entries = append(entries, slot.entries[position+1:]...)
+		newSlot = hamtSlot{
+			hash:    slot.hash,
+			entries: entries,
+		}
+	}
+	slots := make([]hamtSlot, len(n.slots))
+	copy(slots, n.slots)
+	slots[index] = newSlot
+	return &hamtNode{
+		bitmap: n.bitmap,
+		slots:  slots,
+	}, removed
+}
+
+func (n *hamtNode) withoutSlot(bit uint32, index int) *hamtNode {
+	slots := make([]hamtSlot, 0, len(n.slots)-1)
+	slots = append(slots, n.slots[:index]...)
+	slots = append(slots, n.slots[index+1:]...)
+	return &hamtNode{
+		bitmap: n.bitmap &^ bit,
+		slots:  slots,
+	}
+}
+
+//keysEqual compares map keys, skipping the equals function for identical primitives
+func keysEqual(ctx *Context, a *Value, b *Value) bool {
+	if isPrimitive(a.Value) && a.Type == b.Type && a.Value == b.Value {
+		return true
+	}
+	return a.Equals(ctx, b)
+}
+
+func isPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case int64, float64, bool, rune, string:
+		return true
+	}
+	return false
+}
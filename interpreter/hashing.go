@@ -0,0 +1,85 @@
+package interpreter
+
+import (
+	"github.com/ElaraLang/elara/util"
+	"math"
+	"reflect"
+)
+
+//HashCode returns a hash of the value for use in maps. Values that are equal must have the same hash code.
+//Any type can override its hash code with a hashCode extension, which should be done alongside overriding equals.
+func (v *Value) HashCode(ctx *Context) uint64 {
+	extension := ctx.FindExtension(v.Type, "hashCode")
+	if extension != nil {
+		hash, isInt := extension.Value.Value.Value.(*Function).Exec(ctx, []*Value{v}).Value.(int64)
+		if !isInt {
+			panic("hashCode function did not return Int")
+		}
+		return uint64(hash)
+	}
+
+	switch t := v.Value.(type) {
+	case nil:
+		return 0
+	case int64:
+		return uint64(t)
+	case float64:
+		return math.Float64bits(t)
+	case bool:
+		if t {
+			return 1231
+		}
+		return 1237
+	case rune:
+		return uint64(t)
+	case string:
+		//Strings may also be stored as collections of chars, so these must hash in the same way
+		var hash uint64 = 1
+		for _, char := range t {
+			hash = 31*hash + uint64(char)
+		}
+		return hash
+	case *Collection:
+		var hash uint64 = 1
+		t.ForEach(func(_ int, element *Value) bool {
+			hash = 31*hash + element.HashCode(ctx)
+			return true
+		})
+		return hash
+	case *Tuple:
+		var hash uint64 = 7
+		for _, element := range t.Elements {
+			hash = 31*hash + element.HashCode(ctx)
+		}
+		return hash
+	case *Map:
+		//Maps with the same entries are equal regardless of order, so the entries' hashes are combined in an order independent way
+		var hash uint64 = 0
+		for _, entry := range t.Entries() {
+			hash += entry.Key.HashCode(ctx) ^ entry.Value.HashCode(ctx)
+		}
+		return hash
+	case *Set:
+		var hash uint64 = 0
+		for _, element := range t.Elements() {
+			hash += element.HashCode(ctx)
+		}
+		return hash
+	case *Instance:
+		var hash uint64 = 1
+		for _, property := range t.Type.Properties {
+			hash = 31*hash + t.Values[property.Name].HashCode(ctx)
+		}
+		return hash
+	case *Range:
+		return 31*uint64(t.Start) + uint64(t.End)
+	case *GoValue:
+		return t.hashCode()
+	}
+
+	reflected := reflect.ValueOf(v.Value)
+	if reflected.Kind() == reflect.Ptr {
+		return uint64(reflected.Pointer()) //Anything else, such as functions, is compared by identity
+	}
+	return util.Hash(util.Stringify(v.Value))
+}
@@ -0,0 +1,236 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//Requests are built with request(method, url) and the with functions, which return a changed copy of the request, and sent with send.
+//Network failures are returned as Errors. Responses with an error status, such as 404, are still Responses, so that their status and body can be checked.
+
+//An httpFunctionDefiner defines a native from elara/http, with its parameters numbered in order
+type httpFunctionDefiner func(name string, returnType Type, parameters []Parameter, body func(ctx *Context) *Value)
+
+var headersType = &MapType{KeyType: StringType, ValueType: StringType}
+
+var requestType = NewNativeStructType("Request", "elara/http", []Property{
+	{Name: "method", Type: StringType},
+	{Name: "url", Type: StringType},
+	{Name: "headers", Type: headersType},
+	{Name: "body", Type: StringType},
+	{Name: "timeout", Type: IntType}, //Milliseconds, or 0 to wait forever
+})
+
+var responseType = NewNativeStructType("Response", "elara/http", []Property{
+	{Name: "status", Type: IntType},
+	{Name: "headers", Type: headersType}, //Headers with several values have them joined with ", "
+	{Name: "body", Type: StringType},
+})
+
+//defaultTimeout is the timeout of a Request made with request, in milliseconds
+const defaultTimeout = 30000
+
+//DefaultMaxResponseSize is the most bytes of a response body that a request reads, unless it is changed with WithMaxResponseSize
+const DefaultMaxResponseSize = 10 << 20
+
+//maxRedirects is how many redirects a Request follows before it fails, which is the same as Go's default
+const maxRedirects = 10
+
+func newRequestValue(method string, url string, headers *Map, body string, timeout int64) *Value {
+	return NewInstanceValue(requestType,
+		StringValue(method),
+		StringValue(url),
+		NewValue(headersType, headers),
+		StringValue(body),
+		IntValue(timeout),
+	)
+}
+
+//requestOf reads the properties of a Request instance
+func requestOf(value *Value) (method string, url string, headers *Map, body string, timeout int64) {
+	values := value.Value.(*Instance).Values
+	return stringOf(values["method"]),
+		stringOf(values["url"]),
+		values["headers"].Value.(*Map),
+		stringOf(values["body"]),
+		values["timeout"].Value.(int64)
+}
+
+//SendRequest sends a Request instance, returning a Response instance
+func SendRequest(ctx *Context, request *Value) (*Value, error) {
+	method, url, headers, body, timeout := requestOf(request)
+	ctx.permissions.checkNet(url)
+
+	goContext := ctx.limits.goContext() //So that the request stops if the script is cancelled
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		goContext, cancel = context.WithTimeout(goContext, time.Duration(timeout)*time.Millisecond)
+		defer cancel()
+	}
+	httpRequest, err := http.NewRequestWithContext(goContext, strings.ToUpper(method), url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range headers.Entries() {
+		httpRequest.Header.Add(stringOf(entry.Key), stringOf(entry.Value))
+	}
+
+	//Every redirect is checked in the same way as the request, so that an allowed host can't send the script to one that isn't
+	client := &http.Client{
+		CheckRedirect: func(redirect *http.Request, via []*http.Request) error {
+			if permissionError := ctx.permissions.netError(redirect.URL.String()); permissionError != nil {
+				return permissionError
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	var response *http.Response
+	var responseBody []byte
+	ctx.global.blocking(func() { //Let other tasks run while waiting for the response
+		response, err = client.Do(httpRequest)
+		if err != nil {
+			return
+		}
+		defer response.Body.Close()
+		responseBody, err = readResponseBody(response.Body, ctx.limits.responseSize())
+	})
+	var permissionError *PermissionError
+	if errors.As(err, &permissionError) {
+		panic(permissionError) //Stop the script, as if it had requested the URL that it was redirected to
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newResponseValue(int64(response.StatusCode), headersMap(ctx, response.Header), string(responseBody)), nil
+}
+
+//readResponseBody reads a response body, failing instead of reading more than maxSize bytes so that a large or endless response can't use up the process's memory
+func readResponseBody(body io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+	read, err := ioutil.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(read)) > maxSize {
+		return nil, fmt.Errorf("response body is larger than %d bytes", maxSize)
+	}
+	return read, nil
+}
+
+func InitHTTP(ctx *Context) {
+	ctx.types[requestType.TypeName] = requestType
+	ctx.types[responseType.TypeName] = responseType
+
+	definer := func(impure bool) httpFunctionDefiner {
+		return func(name string, returnType Type, parameters []Parameter, body func(ctx *Context) *Value) {
+			for i := range parameters {
+				parameters[i].Position = uint(i)
+			}
+			define(ctx, name, &Function{
+				Signature: Signature{
+					Parameters: parameters,
+					ReturnType: returnType,
+				},
+				Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+					return NonReturningValue(body(ctx))
+				}),
+				impure: impure,
+			})
+		}
+	}
+	httpFunction := definer(false)
+	networkFunction := definer(true) //For the functions that use the network, which parallel functions can't call
+	sendFunction := func(ctx *Context, request *Value) *Value {
+		response, err := SendRequest(ctx, request)
+		if err != nil {
+			return ErrorValue(err.Error())
+		}
+		return response
+	}
+
+	httpFunction("request", requestType, []Parameter{{Name: "method", Type: StringType}, {Name: "url", Type: StringType}}, func(ctx *Context) *Value {
+		return newRequestValue(stringParameter(ctx, 0), stringParameter(ctx, 1), NewMap(headersType), "", defaultTimeout)
+	})
+
+	//withHeader replaces any header with the same name
+	httpFunction("withHeader", requestType, []Parameter{{Name: "this", Type: requestType}, {Name: "name", Type: StringType}, {Name: "value", Type: StringType}}, func(ctx *Context) *Value {
+		method, url, headers, body, timeout := requestOf(ctx.FindParameter(0))
+		headers = headers.Put(ctx, StringValue(http.CanonicalHeaderKey(stringParameter(ctx, 1))), StringValue(stringParameter(ctx, 2)))
+		return newRequestValue(method, url, headers, body, timeout)
+	})
+
+	httpFunction("withBody", requestType, []Parameter{{Name: "this", Type: requestType}, {Name: "body", Type: StringType}}, func(ctx *Context) *Value {
+		method, url, headers, _, timeout := requestOf(ctx.FindParameter(0))
+		return newRequestValue(method, url, headers, stringParameter(ctx, 1), timeout)
+	})
+
+	//withJSON converts the value to JSON for the body, and sets the Content-Type header. It returns an Error if the value can't be converted
+	httpFunction("withJSON", orError(requestType), []Parameter{{Name: "this", Type: requestType}, {Name: "value", Type: AnyType}}, func(ctx *Context) *Value {
+		method, url, headers, _, timeout := requestOf(ctx.FindParameter(0))
+		body, err := StringifyJSON(ctx, ctx.FindParameter(1))
+		if err != nil {
+			return ErrorValue(err.Error())
+		}
+		headers = headers.Put(ctx, StringValue("Content-Type"), StringValue("application/json"))
+		return newRequestValue(method, url, headers, body, timeout)
+	})
+
+	httpFunction("withTimeout", requestType, []Parameter{{Name: "this", Type: requestType}, {Name: "timeout", Type: IntType}}, func(ctx *Context) *Value {
+		method, url, headers, body, _ := requestOf(ctx.FindParameter(0))
+		return newRequestValue(method, url, headers, body, ctx.FindParameter(1).Value.(int64))
+	})
+
+	networkFunction("send", orError(responseType), []Parameter{{Name: "this", Type: requestType}}, func(ctx *Context) *Value {
+		return sendFunction(ctx, ctx.FindParameter(0))
+	})
+
+	//get and post are shortcuts for sending simple requests
+	networkFunction("get", orError(responseType), []Parameter{{Name: "url", Type: StringType}}, func(ctx *Context) *Value {
+		return sendFunction(ctx, newRequestValue("GET", stringParameter(ctx, 0), NewMap(headersType), "", defaultTimeout))
+	})
+
+	networkFunction("post", orError(responseType), []Parameter{{Name: "url", Type: StringType}, {Name: "body", Type: StringType}}, func(ctx *Context) *Value {
+		return sendFunction(ctx, newRequestValue("POST", stringParameter(ctx, 0), NewMap(headersType), stringParameter(ctx, 1), defaultTimeout))
+	})
+
+	//isSuccess checks if the status is between 200 and 299
+	httpFunction("isSuccess", BooleanType, []Parameter{{Name: "this", Type: responseType}}, func(ctx *Context) *Value {
+		status := ctx.FindParameter(0).Value.(*Instance).Values["status"].Value.(int64)
+		return BooleanValue(status >= 200 && status <= 299)
+	})
+
+	//json parses the body of a Response in the same way as parse from elara/json
+	httpFunction("json", AnyType, []Parameter{{Name: "this", Type: responseType}}, func(ctx *Context) *Value {
+		body := stringOf(ctx.FindParameter(0).Value.(*Instance).Values["body"])
+		value, err := ParseJSON(ctx, body)
+		if err != nil {
+			return ErrorValue("Invalid JSON: " + err.Error())
+		}
+		return value
+	})
+
+	//header finds a response header, ignoring the case of its name, or returns Unit if there isn't one
+	httpFunction("header", AnyType, []Parameter{{Name: "this", Type: responseType}, {Name: "name", Type: StringType}}, func(ctx *Context) *Value {
+		headers := ctx.FindParameter(0).Value.(*Instance).Values["headers"].Value.(*Map)
+		value := headers.Get(ctx, StringValue(http.CanonicalHeaderKey(stringParameter(ctx, 1))))
+		if value == nil {
+			return UnitValue()
+		}
+		return value
+	})
+
+	initHTTPServer(ctx, httpFunction, networkFunction)
+}
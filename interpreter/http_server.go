@@ -0,0 +1,362 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+//A server calls an Elara handler for every request, on its own goroutine.
+//Each request runs in its own scope with its own limits, whose parent is the scope that started the server,
+//so handlers can use the script's variables and define their own without affecting other requests.
+//Like tasks, handlers take turns running Elara code, and run while the rest of the script is waiting, eg in wait or await.
+
+var ServerType = NewEmptyType("Server")
+var RouteType = NewEmptyType("Route")
+
+var serverRequestType = NewNativeStructType("ServerRequest", "elara/http", []Property{
+	{Name: "method", Type: StringType},
+	{Name: "path", Type: StringType},
+	{Name: "headers", Type: headersType},
+	{Name: "query", Type: headersType}, //The first value of every query parameter
+	{Name: "params", Type: headersType}, //Parts of the path matched by a route, eg id for /items/:id
+	{Name: "body", Type: StringType},
+})
+
+var handlerType = functionTypeOf(responseType, serverRequestType)
+
+//Server is a running HTTP server, started with listen or serve
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	stopped  chan struct{} //Closed once the server has shut down and every request has finished
+	stopOnce sync.Once
+	err      error
+	mutex    sync.Mutex
+	exit     *Exit //Set if a handler exited, which shuts the server down and stops the code waiting for it in the same way
+}
+
+func (s *Server) String() string {
+	return "Server(" + s.listener.Addr().String() + ")"
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+//Shutdown stops the server from accepting new requests, and returns straight away. Requests that are already running are allowed to finish
+func (s *Server) Shutdown() {
+	s.stopOnce.Do(func() {
+		go func() {
+			_ = s.server.Shutdown(context.Background())
+			close(s.stopped)
+		}()
+	})
+}
+
+//Wait blocks until the server has shut down, returning the error that stopped it if it failed
+func (s *Server) Wait() error {
+	<-s.stopped
+	return s.err
+}
+
+//exited records that a handler exited, and shuts the server down. Only the first exit is kept
+func (s *Server) exited(exit *Exit) {
+	s.mutex.Lock()
+	if s.exit == nil {
+		s.exit = exit
+	}
+	s.mutex.Unlock()
+	s.Shutdown()
+}
+
+//waitFor waits for the server to shut down. If a handler exited, the waiting code exits in the same way
+func (s *Server) waitFor(ctx *Context) *Value {
+	var err error
+	ctx.global.blocking(func() {
+		err = s.Wait()
+	})
+	s.mutex.Lock()
+	exit := s.exit
+	s.mutex.Unlock()
+	if exit != nil {
+		panic(exit)
+	}
+	if err != nil {
+		return ErrorValue(err.Error())
+	}
+	return UnitValue()
+}
+
+//Listen starts a server on the port, calling handler for every request. Port 0 picks any free port
+func Listen(ctx *Context, port int64, handler *Function) (*Server, error) {
+	ctx.permissions.checkListen(port)
+	listener, err := net.Listen("tcp", ":"+strconv.FormatInt(port, 10))
+	if err != nil {
+		return nil, err
+	}
+	ctx.retained = true //Every request runs in a child of this scope, which must outlive the call that started the server
+	server := &Server{
+		listener: listener,
+		stopped:  make(chan struct{}),
+	}
+	server.server = &http.Server{
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			handleRequest(ctx, server, handler, writer, request)
+		}),
+	}
+	go func() {
+		err := server.server.Serve(listener)
+		if err != http.ErrServerClosed {
+			server.err = err
+			server.Shutdown()
+		}
+	}()
+	return server, nil
+}
+
+func handleRequest(ctx *Context, server *Server, handler *Function, writer http.ResponseWriter, request *http.Request) {
+	ctx.global.acquire()
+	defer ctx.global.release()
+	scope := ctx.EnterScope("request "+request.URL.Path, nil, 0)
+	scope.limits = ctx.limits.request(request.Context()) //Stop the handler if the client goes away
+	defer func() {
+		if r := recover(); r != nil {
+			if exit, isExit := r.(*Exit); isExit {
+				server.exited(exit)
+			} else {
+				reportHandlerError(scope, request, r)
+			}
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		scope.Cleanup()
+	}()
+
+	requestValue, err := serverRequestValue(scope, request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := handler.Exec(scope, []*Value{requestValue})
+	writeResponse(writer, response)
+}
+
+//reportHandlerError writes the reason that a handler failed to the script's stderr, which embedders can redirect with WithStderr
+func reportHandlerError(ctx *Context, request *http.Request, reason interface{}) {
+	writeStderr(ctx, fmt.Sprintf("Error handling %s %s: %v\n", request.Method, request.URL.Path, reason))
+}
+
+func serverRequestValue(ctx *Context, request *http.Request) (*Value, error) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	query := NewMap(headersType)
+	for _, name := range sortedKeys(request.URL.Query()) {
+		query = query.Put(ctx, StringValue(name), StringValue(request.URL.Query().Get(name)))
+	}
+	return NewInstanceValue(serverRequestType,
+		StringValue(request.Method),
+		StringValue(request.URL.Path),
+		NewValue(headersType, headersMap(ctx, request.Header)),
+		NewValue(headersType, query),
+		NewValue(headersType, NewMap(headersType)),
+		StringValue(string(body)),
+	), nil
+}
+
+//headersMap converts headers into a map, joining headers with several values with ", "
+func headersMap(ctx *Context, header http.Header) *Map {
+	headers := NewMap(headersType)
+	for _, name := range sortedKeys(header) {
+		headers = headers.Put(ctx, StringValue(name), StringValue(strings.Join(header[name], ", ")))
+	}
+	return headers
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeResponse(writer http.ResponseWriter, response *Value) {
+	values := response.Value.(*Instance).Values
+	for _, entry := range values["headers"].Value.(*Map).Entries() {
+		writer.Header().Set(stringOf(entry.Key), stringOf(entry.Value))
+	}
+	writer.WriteHeader(int(values["status"].Value.(int64)))
+	_, _ = writer.Write([]byte(stringOf(values["body"])))
+}
+
+func newResponseValue(status int64, headers *Map, body string) *Value {
+	return NewInstanceValue(responseType,
+		IntValue(status),
+		NewValue(headersType, headers),
+		StringValue(body),
+	)
+}
+
+//A Route calls a handler for requests with a method and a path matching a pattern, such as /items/:id
+type Route struct {
+	method   string //* for any method
+	segments []string
+	handler  *Function
+}
+
+func (r *Route) String() string {
+	return "Route(" + r.method + " /" + strings.Join(r.segments, "/") + ")"
+}
+
+func pathSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+//match checks if the route matches a request, returning the parameters in the path if it does
+func (r *Route) match(method string, path string) (map[string]string, bool) {
+	if r.method != "*" && !strings.EqualFold(r.method, method) {
+		return nil, false
+	}
+	segments := pathSegments(path)
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+//newRouter creates a handler that calls the first matching route, or responds with 404 if none match
+func newRouter(routes []*Route) *Function {
+	return &Function{
+		Signature: handlerType.Signature,
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			request := ctx.FindParameter(0)
+			values := request.Value.(*Instance).Values
+			for _, route := range routes {
+				params, matches := route.match(stringOf(values["method"]), stringOf(values["path"]))
+				if !matches {
+					continue
+				}
+				paramsMap := NewMap(headersType)
+				for _, segment := range route.segments {
+					if strings.HasPrefix(segment, ":") {
+						paramsMap = paramsMap.Put(ctx, StringValue(segment[1:]), StringValue(params[segment[1:]]))
+					}
+				}
+				matched := NewInstanceValue(serverRequestType,
+					values["method"],
+					values["path"],
+					values["headers"],
+					values["query"],
+					NewValue(headersType, paramsMap),
+					values["body"],
+				)
+				return NonReturningValue(route.handler.Exec(ctx, []*Value{matched}))
+			}
+			return NonReturningValue(newResponseValue(http.StatusNotFound, NewMap(headersType), http.StatusText(http.StatusNotFound)))
+		}),
+	}
+}
+
+func initHTTPServer(ctx *Context, httpFunction httpFunctionDefiner, networkFunction httpFunctionDefiner) {
+	ctx.types[serverRequestType.TypeName] = serverRequestType
+
+	//respond creates a Response for a handler to return
+	httpFunction("respond", responseType, []Parameter{{Name: "status", Type: IntType}, {Name: "body", Type: StringType}}, func(ctx *Context) *Value {
+		return newResponseValue(ctx.FindParameter(0).Value.(int64), NewMap(headersType), stringParameter(ctx, 1))
+	})
+
+	httpFunction("respondJSON", orError(responseType), []Parameter{{Name: "status", Type: IntType}, {Name: "value", Type: AnyType}}, func(ctx *Context) *Value {
+		body, err := StringifyJSON(ctx, ctx.FindParameter(1))
+		if err != nil {
+			return ErrorValue(err.Error())
+		}
+		headers := NewMap(headersType).Put(ctx, StringValue("Content-Type"), StringValue("application/json"))
+		return newResponseValue(ctx.FindParameter(0).Value.(int64), headers, body)
+	})
+
+	httpFunction("withHeader", responseType, []Parameter{{Name: "this", Type: responseType}, {Name: "name", Type: StringType}, {Name: "value", Type: StringType}}, func(ctx *Context) *Value {
+		values := ctx.FindParameter(0).Value.(*Instance).Values
+		headers := values["headers"].Value.(*Map).Put(ctx, StringValue(http.CanonicalHeaderKey(stringParameter(ctx, 1))), StringValue(stringParameter(ctx, 2)))
+		return newResponseValue(values["status"].Value.(int64), headers, stringOf(values["body"]))
+	})
+
+	//route matches a method, or "*" for any method, and a path. Parts of the path starting with : match any text, and are given to the handler in params
+	httpFunction("route", RouteType, []Parameter{{Name: "method", Type: StringType}, {Name: "path", Type: StringType}, {Name: "handler", Type: handlerType}}, func(ctx *Context) *Value {
+		return NewValue(RouteType, &Route{
+			method:   stringParameter(ctx, 0),
+			segments: pathSegments(stringParameter(ctx, 1)),
+			handler:  ctx.FindParameter(2).Value.(*Function),
+		})
+	})
+
+	httpFunction("router", handlerType, []Parameter{{Name: "routes", Type: NewCollectionTypeOf(RouteType)}}, func(ctx *Context) *Value {
+		elements := collectionParameter(ctx, 0).Elements()
+		routes := make([]*Route, len(elements))
+		for i, element := range elements {
+			routes[i] = element.Value.(*Route)
+		}
+		router := newRouter(routes)
+		return NewValue(NewFunctionType(router), router)
+	})
+
+	//listen starts a server without waiting for it, so that it can be shut down later
+	networkFunction("listen", orError(ServerType), []Parameter{{Name: "port", Type: IntType}, {Name: "handler", Type: handlerType}}, func(ctx *Context) *Value {
+		server, err := Listen(ctx, ctx.FindParameter(0).Value.(int64), ctx.FindParameter(1).Value.(*Function))
+		if err != nil {
+			return ErrorValue(err.Error())
+		}
+		return NewValue(ServerType, server)
+	})
+
+	httpFunction("port", IntType, []Parameter{{Name: "this", Type: ServerType}}, func(ctx *Context) *Value {
+		return IntValue(int64(ctx.FindParameter(0).Value.(*Server).Port()))
+	})
+
+	networkFunction("shutdown", UnitType, []Parameter{{Name: "this", Type: ServerType}}, func(ctx *Context) *Value {
+		ctx.FindParameter(0).Value.(*Server).Shutdown()
+		return UnitValue()
+	})
+
+	networkFunction("wait", orError(UnitType), []Parameter{{Name: "this", Type: ServerType}}, func(ctx *Context) *Value {
+		return ctx.FindParameter(0).Value.(*Server).waitFor(ctx)
+	})
+
+	//serve starts a server and waits until it is shut down, which happens gracefully when the process is interrupted
+	networkFunction("serve", orError(UnitType), []Parameter{{Name: "port", Type: IntType}, {Name: "handler", Type: handlerType}}, func(ctx *Context) *Value {
+		server, err := Listen(ctx, ctx.FindParameter(0).Value.(int64), ctx.FindParameter(1).Value.(*Function))
+		if err != nil {
+			return ErrorValue(err.Error())
+		}
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupts)
+		go func() {
+			select {
+			case <-interrupts:
+				server.Shutdown()
+			case <-server.stopped:
+			}
+		}()
+		return server.waitFor(ctx)
+	})
+}
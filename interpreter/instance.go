@@ -35,3 +35,19 @@ func (i *Instance) Equals(ctx *Context, other *Value) bool {
 	}
 	return true
 }
+
+//dataValue gets the value of a property, using its default value if the constructor didn't store one.
+//It returns nil if the property has no value, or is a function, as functions aren't part of an instance's data.
+func (i *Instance) dataValue(property Property) *Value {
+	value, present := i.Values[property.Name]
+	if !present {
+		value = property.DefaultValue
+	}
+	if value == nil {
+		return nil
+	}
+	if _, isFunction := value.Value.(*Function); isFunction {
+		return nil
+	}
+	return value
+}
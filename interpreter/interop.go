@@ -0,0 +1,222 @@
+package interpreter
+
+import (
+	"fmt"
+	"github.com/ElaraLang/elara/util"
+	"reflect"
+	"sort"
+)
+
+//Go values with no Elara equivalent, such as structs, are wrapped in a GoValue when they are converted.
+//Elara code can read and set their exported fields and call their methods, which are found with reflection.
+//Their type is a GoType, which can be given a name so that Elara code can check values with is, or extend it.
+
+//A GoType is the type of wrapped Go values. Structs and pointers to them have the same GoType, as structs are always held by a pointer
+type GoType struct {
+	goType reflect.Type
+}
+
+//NewGoType gives the GoType for a reflect.Type. Each Global has one GoType for every reflect.Type, so that extensions can be found for it
+func NewGoType(ctx *Context, goType reflect.Type) *GoType {
+	if goType.Kind() == reflect.Ptr && goType.Elem().Kind() == reflect.Struct {
+		goType = goType.Elem()
+	}
+	existing, loaded := ctx.global.goTypes.Load(goType)
+	if !loaded {
+		existing, _ = ctx.global.goTypes.LoadOrStore(goType, &GoType{goType: goType})
+	}
+	return existing.(*GoType)
+}
+
+func (t *GoType) Name() string {
+	return t.goType.String()
+}
+
+//Accepts checks if the other type is the same Go type, or implements it if it is an interface
+func (t *GoType) Accepts(otherType Type, _ *Context) bool {
+	other, isGo := otherType.(*GoType)
+	if !isGo {
+		return false
+	}
+	if t.goType.Kind() == reflect.Interface {
+		return other.heldType().Implements(t.goType)
+	}
+	return t == other
+}
+
+//heldType is the type of the reflect.Value that a GoValue of this type holds
+func (t *GoType) heldType() reflect.Type {
+	if t.goType.Kind() == reflect.Struct {
+		return reflect.PtrTo(t.goType)
+	}
+	return t.goType
+}
+
+//A GoValue wraps a Go value, holding structs by a pointer so that their fields can be set
+type GoValue struct {
+	value reflect.Value
+}
+
+//newGoValue wraps a Go value. Structs are copied, and pointers to structs are shared, so that setting a field changes the Go program's struct
+func newGoValue(ctx *Context, value reflect.Value) *Value {
+	goType := NewGoType(ctx, value.Type())
+	if value.Kind() == reflect.Struct {
+		copied := reflect.New(value.Type())
+		copied.Elem().Set(value)
+		value = copied
+	}
+	return NewValue(goType, &GoValue{value: value})
+}
+
+//Interface gives the wrapped Go value. Structs are given as a pointer to them
+func (g *GoValue) Interface() interface{} {
+	return g.value.Interface()
+}
+
+func (g *GoValue) String() string {
+	if stringer, isStringer := g.value.Interface().(fmt.Stringer); isStringer {
+		return stringer.String()
+	}
+	return fmt.Sprint(reflect.Indirect(g.value).Interface())
+}
+
+//equals compares the wrapped values with ==. Structs are compared by their fields rather than by their pointers,
+//as converting a struct copies it into a new one. Values that Go can't compare, such as maps, are only equal to themselves
+func (g *GoValue) equals(other *GoValue) bool {
+	if g == other {
+		return true
+	}
+	if g.value.Type() != other.value.Type() {
+		return false
+	}
+	value, otherValue := g.value, other.value
+	if value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Struct {
+		if value.Pointer() == otherValue.Pointer() {
+			return true
+		}
+		value, otherValue = value.Elem(), otherValue.Elem()
+	}
+	return goEquals(value, otherValue)
+}
+
+//goEquals compares two values of the same type with ==. Comparing interfaces that hold something Go can't compare panics,
+//even if their type is comparable, eg a struct with an interface{} field holding a map, so such values are unequal instead
+func goEquals(value reflect.Value, other reflect.Value) (equal bool) {
+	if !value.Type().Comparable() {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return value.Interface() == other.Interface()
+}
+
+func (g *GoValue) hashCode() uint64 {
+	switch g.value.Kind() {
+	case reflect.Ptr:
+		if g.value.Elem().Kind() == reflect.Struct {
+			return util.Hash(g.String()) //Structs are equal by their fields, so they must hash by them too
+		}
+		return uint64(g.value.Pointer())
+	case reflect.Chan, reflect.UnsafePointer:
+		return uint64(g.value.Pointer())
+	case reflect.Map, reflect.Func, reflect.Slice:
+		return uint64(reflect.ValueOf(g).Pointer())
+	}
+	return util.Hash(g.String())
+}
+
+//field finds an exported field of a struct, returning false if there isn't one
+func (g *GoValue) field(name string) (reflect.Value, bool) {
+	if g.value.Kind() != reflect.Ptr || g.value.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	structField, exists := g.value.Elem().Type().FieldByName(name)
+	if !exists || structField.PkgPath != "" { //Unexported fields have a PkgPath
+		return reflect.Value{}, false
+	}
+	return g.value.Elem().FieldByIndex(structField.Index), true
+}
+
+//member gets an exported field or method of the value, returning nil if there isn't one.
+//Fields holding structs are shared rather than copied, so that eg person.Address.City = "Paris" changes person
+func (g *GoValue) member(ctx *Context, name string) *Value {
+	if field, isField := g.field(name); isField {
+		if field.Kind() == reflect.Struct {
+			return NewValue(NewGoType(ctx, field.Type()), &GoValue{value: field.Addr()})
+		}
+		value, err := ToValue(ctx, field.Interface())
+		if err != nil {
+			panic(err.Error())
+		}
+		return value
+	}
+	method := g.value.MethodByName(name)
+	if !method.IsValid() {
+		return nil
+	}
+	function, err := NewGoFunction(ctx, name, method.Interface())
+	if err != nil {
+		panic(err.Error())
+	}
+	return NewValue(NewFunctionType(function), function)
+}
+
+//set sets an exported field, converting the value to the field's type
+func (g *GoValue) set(name string, value *Value) error {
+	field, isField := g.field(name)
+	if !isField {
+		return fmt.Errorf("%s has no exported field %s", g.value.Type(), name)
+	}
+	converted, err := convertToGo(value, field.Type())
+	if err != nil {
+		return err
+	}
+	field.Set(converted)
+	return nil
+}
+
+//DeclareGoPackage declares a namespace of Go values, so that Elara code can import it, eg import go/strings.
+//Functions are wrapped with NewGoFunction, reflect.Types are defined as types with the member's name, and everything else is converted with ToValue
+func (g *Global) DeclareGoPackage(namespace string, members map[string]interface{}) error {
+	module := g.NewContext(false)
+	module.namespace = namespace
+	module.name = namespace
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names) //So that the same error is returned every time
+	for _, name := range names {
+		member := members[name]
+		if goType, isType := member.(reflect.Type); isType {
+			module.types[name] = NewGoType(module, goType)
+			continue
+		}
+		var value *Value
+		if reflect.TypeOf(member) != nil && reflect.TypeOf(member).Kind() == reflect.Func {
+			function, err := NewGoFunction(module, name, member)
+			if err != nil {
+				return err
+			}
+			value = NewValue(NewFunctionType(function), function)
+		} else {
+			converted, err := ToValue(module, member)
+			if err != nil {
+				return err
+			}
+			value = converted
+		}
+		module.DefineVariable(&Variable{
+			Name:    name,
+			Mutable: false,
+			Type:    value.Type,
+			Value:   value,
+		})
+	}
+	g.declare(namespace, module)
+	return nil
+}
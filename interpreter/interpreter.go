@@ -1,42 +1,341 @@
 package interpreter
 
 import (
-	"fmt"
+	"bufio"
+	"context"
 	"github.com/ElaraLang/elara/parser"
+	"github.com/ElaraLang/elara/util"
+	"io"
+	"os"
 	"reflect"
 )
 
 type Interpreter struct {
-	lines   []parser.Stmt
-	context *Context
+	lines       []parser.Stmt
+	global      *Global
+	context     *Context
+	args        []string
+	exitCode    *int //Set once the script calls exit
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	outputs     []*Output
+	limits      *limits
+	permissions *Permissions
+	clock       Clock
+	loop        *EventLoop
+	running     bool //Set while the interpreter's goroutine is running Elara code
 }
 
-func NewInterpreter(code []parser.Stmt) *Interpreter {
-	return &Interpreter{
-		lines:   code,
-		context: NewContext(true),
+//An Option configures an Interpreter when it is created
+type Option func(interpreter *Interpreter)
+
+//WithArgs sets the command line arguments that the script can read from args
+func WithArgs(args []string) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.args = args
+	}
+}
+
+//WithGlobal makes the interpreter share namespaces with other interpreters using the same Global,
+//for example so that a script can import libraries that were loaded by another interpreter
+func WithGlobal(global *Global) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.global = global
+	}
+}
+
+//WithStepLimit limits how many steps a single Exec can take. Every loop iteration and function call is a step,
+//as is every element that natives such as repetition work through.
+//The steps of the tasks and parallel workers that it starts count towards the same limit, but every request to a server it starts has its own.
+//Going over the limit stops the script with a LimitError
+func WithStepLimit(steps int64) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.limits.maxSteps = steps
+	}
+}
+
+//WithMaxCallDepth limits how deeply functions can call each other, so that unbounded recursion stops with a LimitError
+func WithMaxCallDepth(depth int) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.limits.maxDepth = depth
+	}
+}
+
+//WithMaxResponseSize limits how many bytes of a response body an HTTP request reads, so that a script can't be made to use up memory by a large response.
+//Requests whose response is larger give an Error. It is DefaultMaxResponseSize unless it is set, and 0 removes the limit
+func WithMaxResponseSize(bytes int64) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.limits.maxResponseSize = bytes
+	}
+}
+
+//WithPermissions sets what the script is allowed to do outside of the interpreter. Without it, the script is allowed to do anything.
+//Use NoPermissions to run untrusted scripts
+func WithPermissions(permissions *Permissions) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.permissions = permissions
+	}
+}
+
+//WithClock sets the clock that timers use, eg a VirtualClock so that tests don't have to wait for them
+func WithClock(clock Clock) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.clock = clock
+	}
+}
+
+//WithStdin sets where input and readLine read from, instead of the process's standard input
+func WithStdin(stdin io.Reader) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.stdin = stdin
+	}
+}
+
+//WithStdout sets where stdout writes to, instead of the process's standard output
+func WithStdout(stdout io.Writer) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.stdout = stdout
+	}
+}
+
+//WithStderr sets where stderr writes to, instead of the process's standard error
+func WithStderr(stderr io.Writer) Option {
+	return func(interpreter *Interpreter) {
+		interpreter.stderr = stderr
+	}
+}
+
+func NewInterpreter(code []parser.Stmt, options ...Option) *Interpreter {
+	interpreter := &Interpreter{
+		lines:       code,
+		args:        []string{},
+		stdin:       os.Stdin,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		limits:      &limits{maxResponseSize: DefaultMaxResponseSize},
+		permissions: AllPermissions(),
+		clock:       systemClock{},
+	}
+	for _, option := range options {
+		option(interpreter)
+	}
+	if interpreter.global == nil {
+		interpreter.global = NewGlobal()
 	}
+	interpreter.context = interpreter.global.NewContext(true)
+	interpreter.context.limits = interpreter.limits
+	interpreter.context.permissions = interpreter.permissions
+	stdout := NewOutput(interpreter.stdout)
+	stderr := NewUnbufferedOutput(interpreter.stderr)
+	interpreter.outputs = []*Output{stdout, stderr}
+	InitIO(interpreter.context, bufio.NewReader(interpreter.stdin), stdout, stderr)
+	InitProcess(interpreter.context, interpreter.args)
+	interpreter.loop = NewEventLoop(interpreter.clock, interpreter.Flush)
+	InitTimers(interpreter.context, interpreter.loop)
+	InitConcurrency(interpreter.context, interpreter.loop)
+	return interpreter
 }
-func NewEmptyInterpreter() *Interpreter {
-	return NewInterpreter([]parser.Stmt{})
+func NewEmptyInterpreter(options ...Option) *Interpreter {
+	return NewInterpreter([]parser.Stmt{}, options...)
 }
 
 func (s *Interpreter) ResetLines(lines *[]parser.Stmt) {
 	s.lines = *lines
 }
 
+//Exec runs the interpreter's code, returning the value of every line. Going over a limit panics with a LimitError, and being denied a permission with a PermissionError
 func (s *Interpreter) Exec(scriptMode bool) []*Value {
-	values := make([]*Value, len(s.lines))
-
-	for i := 0; i < len(s.lines); i++ {
-		line := s.lines[i]
-		command := ToCommand(line)
-		res := command.Exec(s.context).Unwrap()
-		values[i] = res
-		if scriptMode {
-			formatted := s.context.Stringify(res) + " " + reflect.TypeOf(res).String()
-			fmt.Println(formatted)
-		}
+	values, err := s.ExecContext(context.Background(), scriptMode)
+	if err != nil {
+		panic(err)
 	}
 	return values
 }
+
+//ExecContext runs the interpreter's code, stopping if the context.Context is cancelled or its deadline passes.
+//If the code is stopped early, or goes over a limit, the error is a LimitError, if it is denied a permission the error is a PermissionError, and the values of any lines that didn't run are nil.
+func (s *Interpreter) ExecContext(goContext context.Context, scriptMode bool) (values []*Value, err error) {
+	values = make([]*Value, len(s.lines))
+	if s.exitCode != nil {
+		return values, nil
+	}
+	err = s.run(goContext, func() {
+		for i := 0; i < len(s.lines); i++ {
+			line := s.lines[i]
+			command := ToCommand(line)
+			res := command.Exec(s.context).Unwrap()
+			values[i] = res
+			if scriptMode {
+				formatted := s.context.Stringify(res) + " " + reflect.TypeOf(res).String()
+				s.outputs[0].Write(formatted + "\n")
+			}
+		}
+	})
+	return values, err
+}
+
+//Context is the global context that the interpreter runs code in
+func (s *Interpreter) Context() *Context {
+	return s.context
+}
+
+//Global finds a variable defined in the interpreter's global scope, or returns nil if there isn't one.
+//Lazy bindings are evaluated the first time they are read, in the same way as Call
+func (s *Interpreter) Global(name string) *Value {
+	variable := s.context.FindVariable(util.Hash(name))
+	if variable == nil {
+		return nil
+	}
+	if variable.Lazy == nil {
+		return variable.Value
+	}
+	var value *Value
+	err := s.run(context.Background(), func() {
+		value = variable.Get(s.context)
+	})
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+//Define defines an immutable variable in the interpreter's global scope
+func (s *Interpreter) Define(name string, value *Value) {
+	s.context.DefineVariable(&Variable{
+		Name:    name,
+		Mutable: false,
+		Type:    value.Type,
+		Value:   value,
+	})
+}
+
+//DefineType gives a type a name in the interpreter's global scope, eg so that Elara code can check for a GoType with is
+func (s *Interpreter) DefineType(name string, t Type) {
+	s.context.types[name] = t
+}
+
+//DeclareGoPackage declares a namespace of Go values that Elara code can import, in the same way as Global.DeclareGoPackage
+func (s *Interpreter) DeclareGoPackage(namespace string, members map[string]interface{}) error {
+	return s.context.global.DeclareGoPackage(namespace, members)
+}
+
+//Call calls a function from Go, flushing any output that it writes. It returns nil if the function calls exit
+func (s *Interpreter) Call(function *Function, arguments []*Value) *Value {
+	result, err := s.CallContext(context.Background(), function, arguments)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+//CallContext calls a function in the same way as Call, but stops if the context.Context is cancelled, like ExecContext
+func (s *Interpreter) CallContext(goContext context.Context, function *Function, arguments []*Value) (result *Value, err error) {
+	err = s.run(goContext, func() {
+		result = function.Exec(s.context, arguments)
+	})
+	return result, err
+}
+
+//ExitCode gives the code that the script passed to exit, if it has called it
+func (s *Interpreter) ExitCode() (int, bool) {
+	if s.exitCode == nil {
+		return 0, false
+	}
+	return *s.exitCode, true
+}
+
+//RunMain calls the script's main function if it has one, and returns the code that the process should exit with.
+//main can take the command line arguments as a [String], and can return an Int to use as the exit code.
+func (s *Interpreter) RunMain() int {
+	if s.exitCode != nil {
+		return *s.exitCode
+	}
+	mainVariable := s.context.FindVariable(util.Hash("main"))
+	if mainVariable == nil {
+		return 0
+	}
+	err := s.run(context.Background(), func() {
+		main, isFunction := mainVariable.Get(s.context).Value.(*Function)
+		if isFunction {
+			s.callMain(main)
+		}
+	})
+	if err != nil {
+		panic(err)
+	}
+	if s.exitCode != nil {
+		return *s.exitCode
+	}
+	return 0
+}
+
+func (s *Interpreter) callMain(main *Function) {
+	parameters := make([]*Value, 0, 1)
+	if len(main.Signature.Parameters) == 1 {
+		parameters = append(parameters, stringCollectionValue(s.args))
+	}
+	result := main.Exec(s.context, parameters)
+	if result == nil {
+		return
+	}
+	if code, isInt := result.Value.(int64); isInt {
+		exitCode := int(code)
+		s.exitCode = &exitCode
+	}
+}
+
+//Flush writes anything that is still buffered in stdout and stderr
+func (s *Interpreter) Flush() {
+	for _, output := range s.outputs {
+		output.Flush()
+	}
+}
+
+//run runs some of the script, with the interpreter's limits, then runs any timers and waits for any tasks that it started, and flushes its output afterwards.
+//If the script calls exit, run records the exit code and returns normally. If it goes over a limit or is denied a permission, run returns the LimitError or PermissionError.
+//Calling back into the interpreter from a native, eg from a Go function given to Define, just runs the script as part of the run that is already happening.
+func (s *Interpreter) run(goContext context.Context, script func()) (err error) {
+	if s.running {
+		script()
+		return nil
+	}
+	s.global.acquire()
+	s.running = true
+	defer func() {
+		s.running = false
+		s.global.release()
+	}()
+
+	runContext, cancel := context.WithCancel(goContext)
+	s.limits.start(runContext)
+	finished := false
+	defer s.Flush()
+	defer func() {
+		r := recover()
+		switch t := r.(type) {
+		case nil:
+		case *Exit:
+			s.exitCode = &t.Code
+			s.loop.Clear()
+		case *LimitError:
+			err = t
+		case *PermissionError:
+			err = t
+		default:
+			panic(r)
+		}
+	}()
+	defer func() {
+		cancel() //Stop any tasks that are still running, eg because the script exited
+		s.loop.waitForTasks(s.global)
+		failed := s.loop.failedTasks()
+		if finished { //Otherwise the script has already stopped for a reason of its own
+			checkUnawaited(s.context, failed)
+		}
+	}()
+	script()
+	s.loop.Run(runContext, s.global) //Keep running until every timer and task has finished
+	finished = true
+	return nil
+}
@@ -120,6 +120,12 @@ func InitInts(ctx *Context) {
 	})
 }
 
+//defineImpure defines a native with side effects, which parallel functions aren't allowed to call
+func defineImpure(ctx *Context, name string, function *Function) {
+	function.impure = true
+	define(ctx, name, function)
+}
+
 func define(ctx *Context, name string, function *Function) {
 	function.name = &name
 	funcType := NewFunctionType(function)
@@ -0,0 +1,120 @@
+package interpreter
+
+import (
+	"bufio"
+	"github.com/ElaraLang/elara/util"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//An Output is somewhere that a script can write to, such as stdout or stderr.
+//Writes to a buffered Output only reach the underlying writer when it is flushed.
+//The Interpreter flushes stdout when it finishes executing, on every turn of the event loop, and before reading input, and doesn't buffer stderr.
+//Outputs can be written to from several goroutines, such as the handlers of an HTTP server.
+type Output struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	buffer *bufio.Writer //The same as writer, or nil if the Output isn't buffered
+}
+
+func NewOutput(writer io.Writer) *Output {
+	buffer := bufio.NewWriter(writer)
+	return &Output{writer: buffer, buffer: buffer}
+}
+
+//NewUnbufferedOutput creates an Output that writes straight to writer, such as for errors, which should be seen as soon as they happen
+func NewUnbufferedOutput(writer io.Writer) *Output {
+	return &Output{writer: writer}
+}
+
+func (o *Output) Write(value string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	_, err := io.WriteString(o.writer, value)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *Output) Flush() {
+	if o.buffer == nil {
+		return
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	err := o.buffer.Flush()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (o *Output) String() string {
+	return "Output"
+}
+
+//writeStderr writes a message to the script's stderr, or to the process's standard error if the script doesn't have one
+func writeStderr(ctx *Context, message string) {
+	stderr := ctx.FindVariable(util.Hash("stderr"))
+	if stderr == nil {
+		_, _ = os.Stderr.WriteString(message)
+		return
+	}
+	stderr.Value.Value.(*Output).Write(message)
+}
+
+//InitIO defines stdout, stderr, input and readLine, using the given streams
+func InitIO(ctx *Context, stdin *bufio.Reader, stdout *Output, stderr *Output) {
+	ctx.DefineVariable(&Variable{
+		Name:    "stdout",
+		Mutable: false,
+		Type:    OutputType,
+		Value:   NewValue(OutputType, stdout),
+	})
+	ctx.DefineVariable(&Variable{
+		Name:    "stderr",
+		Mutable: false,
+		Type:    OutputType,
+		Value:   NewValue(OutputType, stderr),
+	})
+
+	//readLine reads a whole line, without the line break. At the end of the input it returns Unit
+	readLine := func() (string, bool) {
+		stdout.Flush() //Make sure that any prompt is visible before waiting for input
+		line, err := stdin.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return "", false
+			}
+			panic(err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true
+	}
+
+	defineImpure(ctx, "readLine", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			line, read := readLine()
+			if !read {
+				return NonReturningValue(UnitValue())
+			}
+			return NonReturningValue(StringValue(line))
+		}),
+	})
+
+	//input is the same as readLine, but gives an empty String at the end of the input
+	defineImpure(ctx, "input", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{},
+			ReturnType: StringType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			line, _ := readLine()
+			return NonReturningValue(StringValue(line))
+		}),
+	})
+}
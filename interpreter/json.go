@@ -0,0 +1,394 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//JSON objects are parsed into {String : Any} maps, keeping the order of their keys, and arrays into collections.
+//Numbers without a fraction or exponent become Ints, other numbers become Floats, and null becomes Unit.
+
+//ParseJSON parses JSON text into an Elara value
+func ParseJSON(ctx *Context, text string) (*Value, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	value, err := readJSONValue(ctx, decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the end of the JSON value")
+	}
+	return value, nil
+}
+
+func readJSONValue(ctx *Context, decoder *json.Decoder) (*Value, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("unexpected end of JSON input")
+		}
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			keys := make([]*Value, 0)
+			values := make([]*Value, 0)
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readJSONValue(ctx, decoder)
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, StringValue(keyToken.(string)))
+				values = append(values, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			object := NewMap(&MapType{
+				KeyType:   StringType,
+				ValueType: commonElementType(ctx, values),
+			})
+			for i, key := range keys {
+				object = object.Put(ctx, key, values[i])
+			}
+			return NewValue(object.MapType, object), nil
+		}
+		elements := make([]*Value, 0)
+		for decoder.More() {
+			element, err := readJSONValue(ctx, decoder)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return CollectionValue(commonElementType(ctx, elements), elements), nil
+	case string:
+		return StringValue(t), nil
+	case json.Number:
+		integer, err := strconv.ParseInt(string(t), 10, 64)
+		if err == nil {
+			return IntValue(integer), nil
+		}
+		float, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return FloatValue(float), nil
+	case bool:
+		return BooleanValue(t), nil
+	case nil:
+		return UnitValue(), nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", token)
+}
+
+//StringifyJSON converts a value into JSON text. Struct instances become objects with their properties in the order they were declared.
+func StringifyJSON(ctx *Context, value *Value) (string, error) {
+	builder := &strings.Builder{}
+	err := writeJSON(ctx, builder, value)
+	if err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func writeJSON(ctx *Context, builder *strings.Builder, value *Value) error {
+	switch t := value.Value.(type) {
+	case nil:
+		if value.Type != UnitType {
+			return fmt.Errorf("cannot convert value of type %s to JSON", value.Type.Name())
+		}
+		builder.WriteString("null")
+	case int64:
+		builder.WriteString(strconv.FormatInt(t, 10))
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return fmt.Errorf("cannot convert %s to JSON", value.String())
+		}
+		formatted := strconv.FormatFloat(t, 'g', -1, 64)
+		if !strings.ContainsAny(formatted, ".e") {
+			formatted += ".0" //Keep whole Floats as Floats when they are parsed again
+		}
+		builder.WriteString(formatted)
+	case bool:
+		builder.WriteString(strconv.FormatBool(t))
+	case rune:
+		writeJSONString(builder, string(t))
+	case string:
+		writeJSONString(builder, t)
+	case *Collection:
+		if t.ElementType == CharType {
+			writeJSONString(builder, t.elemsAsString())
+			return nil
+		}
+		return writeJSONArray(ctx, builder, t.Elements())
+	case *Set:
+		return writeJSONArray(ctx, builder, t.Elements())
+	case *Tuple:
+		return writeJSONArray(ctx, builder, t.Elements)
+	case *Map:
+		builder.WriteRune('{')
+		for i, entry := range t.Entries() {
+			if i != 0 {
+				builder.WriteRune(',')
+			}
+			writeJSONString(builder, ctx.Stringify(entry.Key)) //JSON only allows string keys
+			builder.WriteRune(':')
+			if err := writeJSON(ctx, builder, entry.Value); err != nil {
+				return err
+			}
+		}
+		builder.WriteRune('}')
+	case *Instance:
+		builder.WriteRune('{')
+		written := 0
+		for _, property := range t.Type.Properties {
+			if !t.Type.canAccess(property, ctx) {
+				continue //Restricted fields are left out, rather than exposing them to code that couldn't read them
+			}
+			propertyValue := t.dataValue(property)
+			if propertyValue == nil {
+				continue
+			}
+			if written != 0 {
+				builder.WriteRune(',')
+			}
+			writeJSONString(builder, property.Name)
+			builder.WriteRune(':')
+			if err := writeJSON(ctx, builder, propertyValue); err != nil {
+				return err
+			}
+			written++
+		}
+		builder.WriteRune('}')
+	default:
+		return fmt.Errorf("cannot convert value of type %s to JSON", value.Type.Name())
+	}
+	return nil
+}
+
+func writeJSONArray(ctx *Context, builder *strings.Builder, elements []*Value) error {
+	builder.WriteRune('[')
+	for i, element := range elements {
+		if i != 0 {
+			builder.WriteRune(',')
+		}
+		if err := writeJSON(ctx, builder, element); err != nil {
+			return err
+		}
+	}
+	builder.WriteRune(']')
+	return nil
+}
+
+func writeJSONString(builder *strings.Builder, value string) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value) //Encoding a string can't fail
+	builder.Write(bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'}))
+}
+
+//DecodeJSON converts a parsed JSON value into the given type, checking that it has the right shape.
+//Objects are decoded into structs by matching their keys to the struct's properties.
+//Properties with a default value may be left out, but any other missing property is an error. Extra keys are ignored.
+func DecodeJSON(ctx *Context, value *Value, target Type) (*Value, error) {
+	return decodeJSONValue(ctx, value, target, "")
+}
+
+func decodeJSONValue(ctx *Context, value *Value, target Type, path string) (*Value, error) {
+	mismatch := func() error {
+		location := ""
+		if path != "" {
+			location = " at " + path
+		}
+		return fmt.Errorf("expected %s%s but found %s", target.Name(), location, value.String())
+	}
+
+	switch t := target.(type) {
+	case *StructType:
+		object, isObject := value.Value.(*Map)
+		if !isObject {
+			return nil, mismatch()
+		}
+		values := make(map[string]*Value, len(t.Properties))
+		for _, property := range t.Properties {
+			field := object.Get(ctx, StringValue(property.Name))
+			if field == nil {
+				if property.DefaultValue == nil {
+					return nil, fmt.Errorf("missing property %s", joinJSONPath(path, property.Name))
+				}
+				values[property.Name] = property.DefaultValue
+				continue
+			}
+			decoded, err := decodeJSONValue(ctx, field, property.Type, joinJSONPath(path, property.Name))
+			if err != nil {
+				return nil, err
+			}
+			values[property.Name] = decoded
+		}
+		return NewValue(t, &Instance{
+			Type:   t,
+			Values: values,
+		}), nil
+
+	case *CollectionType:
+		if t.ElementType == CharType {
+			if _, isString := value.Value.(string); !isString {
+				return nil, mismatch()
+			}
+			return value, nil
+		}
+		array, isArray := value.Value.(*Collection)
+		if !isArray {
+			return nil, mismatch()
+		}
+		elements, err := decodeJSONElements(ctx, array.Elements(), t.ElementType, path)
+		if err != nil {
+			return nil, err
+		}
+		return CollectionValue(t.ElementType, elements), nil
+
+	case *SetType:
+		array, isArray := value.Value.(*Collection)
+		if !isArray {
+			return nil, mismatch()
+		}
+		elements, err := decodeJSONElements(ctx, array.Elements(), t.ElementType, path)
+		if err != nil {
+			return nil, err
+		}
+		set := NewSet(t)
+		for _, element := range elements {
+			set = set.Add(ctx, element)
+		}
+		return SetValue(set), nil
+
+	case *MapType:
+		object, isObject := value.Value.(*Map)
+		if !isObject || !t.KeyType.Accepts(StringType, ctx) {
+			return nil, mismatch()
+		}
+		decoded := NewMap(t)
+		for _, entry := range object.Entries() {
+			decodedValue, err := decodeJSONValue(ctx, entry.Value, t.ValueType, joinJSONPath(path, stringOf(entry.Key)))
+			if err != nil {
+				return nil, err
+			}
+			decoded = decoded.Put(ctx, entry.Key, decodedValue)
+		}
+		return NewValue(t, decoded), nil
+	}
+
+	if target == FloatType {
+		asInt, isInt := value.Value.(int64)
+		if isInt {
+			return FloatValue(float64(asInt)), nil
+		}
+	}
+	if !target.Accepts(value.Type, ctx) {
+		return nil, mismatch()
+	}
+	return value, nil
+}
+
+func decodeJSONElements(ctx *Context, elements []*Value, elementType Type, path string) ([]*Value, error) {
+	decoded := make([]*Value, len(elements))
+	for i, element := range elements {
+		value, err := decodeJSONValue(ctx, element, elementType, path+"["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = value
+	}
+	return decoded, nil
+}
+
+func joinJSONPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func InitJSON(ctx *Context) {
+	define(ctx, "parse", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "text",
+					Type: StringType,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		//Returns an Error if the text is not valid JSON
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			value, err := ParseJSON(ctx, stringParameter(ctx, 0))
+			if err != nil {
+				return NonReturningValue(ErrorValue("Invalid JSON: " + err.Error()))
+			}
+			return NonReturningValue(value)
+		}),
+	})
+
+	define(ctx, "stringify", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "value",
+					Type: AnyType,
+				},
+			},
+			ReturnType: orError(StringType),
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			text, err := StringifyJSON(ctx, ctx.FindParameter(0))
+			if err != nil {
+				return NonReturningValue(ErrorValue(err.Error()))
+			}
+			return NonReturningValue(StringValue(text))
+		}),
+	})
+
+	decodeType := NewTypeParameter("T", nil)
+	define(ctx, "decode", &Function{
+		Signature: Signature{
+			TypeParameters: []*TypeParameter{decodeType},
+			Parameters: []Parameter{
+				{
+					Name: "text",
+					Type: StringType,
+				},
+			},
+			ReturnType: orError(decodeType),
+		},
+		//Decodes JSON text into the type given as a type argument, eg decode<Person>(text)
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			target := ctx.FindType(decodeType.Name())
+			parsed, err := ParseJSON(ctx, stringParameter(ctx, 0))
+			if err != nil {
+				return NonReturningValue(ErrorValue("Invalid JSON: " + err.Error()))
+			}
+			decoded, err := DecodeJSON(ctx, parsed, target)
+			if err != nil {
+				return NonReturningValue(ErrorValue("Cannot decode JSON into " + target.Name() + ": " + err.Error()))
+			}
+			return NonReturningValue(decoded)
+		}),
+	})
+}
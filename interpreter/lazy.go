@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//A LazyValue holds the initializer of a `let lazy` binding.
+//It is evaluated at most once, on first access, and the result is memoized for every later access.
+type LazyValue struct {
+	name         string
+	initializer  Command
+	context      *Context //The context the binding was defined in, or a snapshot of it once its scope has exited
+	declaredType Type     //May be nil if the binding has no explicit type
+	variable     *Variable
+
+	mutex      sync.Mutex    //Only held briefly, never while the initializer runs
+	evaluating chan struct{} //Closed when the evaluation in progress finishes, or nil if there isn't one
+	evaluated  int32         //Set atomically once value is set, so that it can be checked without waiting for an evaluation in progress
+	value      *Value
+}
+
+//lazyFrame is a linked list of the lazy bindings currently being evaluated by one chain of execution.
+//It is threaded through contexts so that a binding which (directly or indirectly) depends on itself can be reported,
+//rather than deadlocking on its own mutex.
+type lazyFrame struct {
+	lazy *LazyValue
+	next *lazyFrame
+}
+
+func NewLazyValue(name string, initializer Command, ctx *Context, declaredType Type) *LazyValue {
+	lazy := &LazyValue{
+		name:         name,
+		initializer:  initializer,
+		context:      ctx,
+		declaredType: declaredType,
+	}
+	ctx.lazies = append(ctx.lazies, lazy)
+	return lazy
+}
+
+//capture is called when the scope the binding was defined in exits. If the binding hasn't been evaluated yet,
+//it takes a snapshot of the scope, as function literals do, so that the scope itself can be reused
+func (l *LazyValue) capture(scope *Context) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.context == scope {
+		l.context = scope.Clone()
+	}
+}
+
+func (l *LazyValue) Force(ctx *Context) *Value {
+	for frame := ctx.lazyChain; frame != nil; frame = frame.next {
+		if frame.lazy == l {
+			panic("Cycle detected while evaluating lazy binding " + l.name + ": " + describeCycle(ctx.lazyChain, l))
+		}
+	}
+
+	for {
+		l.mutex.Lock()
+		if atomic.LoadInt32(&l.evaluated) == 1 {
+			l.mutex.Unlock()
+			return l.value
+		}
+		evaluating := l.evaluating
+		if evaluating == nil {
+			break
+		}
+		l.mutex.Unlock()
+		//Another task is evaluating the binding, and its initializer may be waiting for this one, eg to send to a channel,
+		//so let other goroutines run until it has finished. If it failed, this one tries again
+		ctx.wait(func() {
+			<-evaluating
+		})
+	}
+	evaluating := make(chan struct{})
+	l.evaluating = evaluating
+	context := l.context
+	l.mutex.Unlock()
+	defer func() {
+		l.mutex.Lock()
+		l.evaluating = nil
+		l.mutex.Unlock()
+		close(evaluating)
+	}()
+
+	scope := context.EnterScope(l.name, context.function, 0)
+	scope.parameters = context.parameters
+	scope.limits = ctx.limits //Counted against whoever first needed the value, which may be a task or a parallel worker
+	scope.parallel = ctx.parallel
+	scope.lazyChain = &lazyFrame{
+		lazy: l,
+		next: ctx.lazyChain,
+	}
+	value := l.initializer.Exec(scope).Unwrap()
+	scope.Cleanup()
+
+	if value == nil {
+		panic("Initializer of lazy binding " + l.name + " returned nil")
+	}
+	if l.declaredType != nil && !l.declaredType.Accepts(value.Type, ctx) {
+		panic("Cannot use value of type " + value.Type.Name() + " in place of " + l.declaredType.Name() + " for variable " + l.name)
+	}
+	if l.variable != nil && l.variable.Type == nil {
+		l.variable.Type = value.Type
+	}
+
+	l.mutex.Lock()
+	l.value = value
+	atomic.StoreInt32(&l.evaluated, 1)
+	l.context = nil //Let the snapshot be collected, the binding doesn't need it any more
+	l.mutex.Unlock()
+	return value
+}
+
+//evaluatedValue returns the value of the binding, and false if it hasn't been evaluated yet
+func (l *LazyValue) evaluatedValue() (*Value, bool) {
+	if atomic.LoadInt32(&l.evaluated) == 0 {
+		return nil, false
+	}
+	return l.value, true
+}
+
+func describeCycle(chain *lazyFrame, repeated *LazyValue) string {
+	names := []string{repeated.name}
+	for frame := chain; frame != nil; frame = frame.next {
+		names = append(names, frame.lazy.name)
+		if frame.lazy == repeated {
+			break
+		}
+	}
+	//The chain is innermost-first, but a cycle reads more naturally in evaluation order
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, " -> ")
+}
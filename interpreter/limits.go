@@ -0,0 +1,130 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var ErrStepLimitExceeded = errors.New("step limit exceeded")
+var ErrCallDepthExceeded = errors.New("maximum call depth exceeded")
+
+//A LimitError stops a script that has run out of steps, called functions too deeply, or whose context.Context was cancelled.
+//Err is ErrStepLimitExceeded, ErrCallDepthExceeded, or the error from the context.Context, so it can be checked with errors.Is.
+type LimitError struct {
+	Err error
+}
+
+func (e *LimitError) Error() string {
+	return "Execution stopped: " + e.Err.Error()
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+//How often, in steps, to check whether the context.Context has been cancelled
+const cancellationCheckInterval = 64
+
+//limits tracks the resources that an Interpreter has used during a single run.
+//Every loop iteration and function call is a step, which is where a script could run forever.
+//Natives whose work grows with the size of their input, without calling a function for each element, also count a step per element.
+type limits struct {
+	context  context.Context //Tasks started by the script are cancelled along with it
+	done     <-chan struct{}
+	err      func() error
+	steps    *int64 //Shared with the run's tasks and parallel workers, which can run at the same time, so it is only used atomically
+	maxSteps int64  //0 for no limit
+	depth    int
+	maxDepth int //0 for no limit
+
+	maxResponseSize int64 //The most bytes of a response body that an HTTP request reads, or 0 for no limit
+}
+
+//start resets the limits for a new run, which can be cancelled with the given context.Context
+func (l *limits) start(goContext context.Context) {
+	l.context = goContext
+	l.done = goContext.Done()
+	l.err = goContext.Err
+	l.steps = new(int64)
+	l.depth = 0
+}
+
+//goContext is the context.Context of the current run, which blocking natives stop waiting on if it is cancelled
+func (l *limits) goContext() context.Context {
+	if l == nil || l.context == nil {
+		return context.Background()
+	}
+	return l.context
+}
+
+//responseSize is the most bytes of a response body that an HTTP request reads, or 0 for no limit
+func (l *limits) responseSize() int64 {
+	if l == nil {
+		return DefaultMaxResponseSize
+	}
+	return l.maxResponseSize
+}
+
+//child creates the limits for a task or parallel worker started by this run, which stop when goContext is cancelled.
+//Its steps count towards this run's, so that starting more tasks doesn't give a script more steps, but it has its own call depth
+func (l *limits) child(goContext context.Context) *limits {
+	child := l.request(goContext)
+	if l != nil {
+		child.steps = l.steps
+	}
+	return child
+}
+
+//request creates the limits for a request handled by a server that this run started, which stop when goContext is cancelled.
+//Servers can run for as long as the program does, so each request has its own steps, with the same maximums as this run
+func (l *limits) request(goContext context.Context) *limits {
+	child := &limits{}
+	if l != nil {
+		child.maxSteps = l.maxSteps
+		child.maxDepth = l.maxDepth
+		child.maxResponseSize = l.maxResponseSize
+	}
+	child.start(goContext)
+	return child
+}
+
+func (l *limits) step() {
+	l.stepBy(1)
+}
+
+//stepBy counts several steps at once, such as one for each element that a native works through
+func (l *limits) stepBy(count int64) {
+	if l == nil || count <= 0 {
+		return
+	}
+	steps := atomic.AddInt64(l.steps, count)
+	if l.maxSteps != 0 && steps > l.maxSteps {
+		panic(&LimitError{Err: ErrStepLimitExceeded})
+	}
+	if l.done != nil && steps/cancellationCheckInterval != (steps-count)/cancellationCheckInterval {
+		select {
+		case <-l.done:
+			panic(&LimitError{Err: l.err()})
+		default:
+		}
+	}
+}
+
+func (l *limits) enterCall() {
+	if l == nil {
+		return
+	}
+	l.depth++
+	if l.maxDepth != 0 && l.depth > l.maxDepth {
+		panic(&LimitError{Err: ErrCallDepthExceeded})
+	}
+	l.step()
+}
+
+func (l *limits) exitCall() {
+	if l == nil {
+		return
+	}
+	l.depth--
+}
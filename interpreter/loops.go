@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"github.com/ElaraLang/elara/parser"
+	"github.com/ElaraLang/elara/util"
+)
+
+type ForCommand struct {
+	label      string
+	pattern    parser.Pattern
+	collection Command
+	body       Command
+}
+
+func (c *ForCommand) Exec(ctx *Context) *ReturnedValue {
+	collection := c.collection.Exec(ctx).Unwrap()
+	var result *ReturnedValue
+	iterate(collection, func(element *Value) bool {
+		//Each iteration gets a fresh scope so that the loop variable can be rebound
+		scope := enterLoopScope(ctx, "for")
+		bindPattern(scope, c.pattern, element, false, false)
+		var stop bool
+		result, stop = loopControl(c.label, c.body.Exec(scope))
+		scope.Cleanup()
+		return !stop
+	})
+	if result == nil {
+		return NilValue()
+	}
+	return result
+}
+
+//enterLoopScope creates the scope for a single iteration of a loop.
+//Parameters aren't looked up through parent scopes, so the loop shares the enclosing function's.
+func enterLoopScope(ctx *Context, name string) *Context {
+	scope := ctx.EnterScope(name, ctx.function, 0)
+	scope.parameters = ctx.parameters
+	scope.limits.step()
+	return scope
+}
+
+//iterate calls the consumer with every element of a collection, set, map or range until it returns false.
+//Map entries are given to the consumer as (key, value) tuples
+func iterate(value *Value, consumer func(element *Value) bool) {
+	switch t := value.Value.(type) {
+	case *Collection:
+		t.elements.forEach(func(_ int, element interface{}) bool {
+			return consumer(element.(*Value))
+		})
+	case string:
+		for _, char := range t {
+			if !consumer(CharValue(char)) {
+				return
+			}
+		}
+	case *Set:
+		for _, element := range t.Elements() {
+			if !consumer(element) {
+				return
+			}
+		}
+	case *Map:
+		for _, entry := range t.Entries() {
+			if !consumer(TupleValue(entry.Key, entry.Value)) {
+				return
+			}
+		}
+	case *Range:
+		for i := t.Start; i < t.End; i++ {
+			if !consumer(IntValue(i)) {
+				return
+			}
+		}
+	default:
+		panic("Cannot iterate over value " + util.Stringify(value.Value) + " of type " + value.Type.Name())
+	}
+}
+
+//loopControl works out what a loop should do after running its body once.
+//If the loop should stop, the returned value is what the loop itself should return, which may be a return or jump that is still unwinding.
+func loopControl(label string, result *ReturnedValue) (*ReturnedValue, bool) {
+	if result.IsReturning {
+		return result, true
+	}
+	if result.Jump == NoJump {
+		result.clean()
+		return nil, false
+	}
+	if result.Label != "" && result.Label != label {
+		return result, true //Jumping to an outer loop
+	}
+	jump := result.Jump
+	result.clean()
+	if jump == BreakJump {
+		return NilValue(), true
+	}
+	return nil, false
+}
+
+type JumpCommand struct {
+	jump  JumpType
+	label string
+}
+
+func (c *JumpCommand) Exec(_ *Context) *ReturnedValue {
+	return JumpValue(c.jump, c.label)
+}
@@ -10,33 +10,150 @@ type MapType struct {
 	ValueType Type
 }
 
+//Maps are persistent, so adding or removing an entry gives a new map and leaves the original unchanged.
+//Entries are found with a hash array mapped trie, and a persistent vector remembers the order they were inserted in.
 type Map struct {
-	MapType  *MapType
-	Elements []*Entry
+	MapType *MapType
+	root    *hamtNode
+	order   *vector //Removing an entry leaves a nil gap, until there are enough gaps to be worth compacting
+	size    int
 }
 
 type Entry struct {
-	Key   *Value
-	Value *Value
+	Key      *Value
+	Value    *Value
+	hash     uint64
+	position int //Position in the map's order
 }
 
-func (m *Map) Get(ctx *Context, key *Value) *Value {
-	for _, element := range m.Elements {
-		if element.Key.Equals(ctx, key) {
-			return element.Value
-		}
+func NewMap(mapType *MapType) *Map {
+	return &Map{
+		MapType: mapType,
+		root:    emptyHamt,
+		order:   emptyVector,
 	}
-	return nil
 }
-func MapOf(elements []*Entry) *Map {
+
+func MapOf(ctx *Context, elements []*Entry) *Map {
+	keyTypes := make([]Type, len(elements))
+	valueTypes := make([]Type, len(elements))
+	for i, element := range elements {
+		keyTypes[i] = element.Key.Type
+		valueTypes[i] = element.Value.Type
+	}
 	mapType := &MapType{
-		KeyType:   AnyType,
-		ValueType: AnyType, //tfw type inference
+		KeyType:   CommonType(keyTypes, ctx),
+		ValueType: CommonType(valueTypes, ctx),
+	}
+	m := NewMap(mapType)
+	for _, element := range elements {
+		m = m.Put(ctx, element.Key, element.Value)
+	}
+	return m
+}
+
+//Equals checks that both maps have equal values for the same keys, regardless of their order
+func (m *Map) Equals(ctx *Context, other *Value) bool {
+	otherAsMap, otherIsMap := other.Value.(*Map)
+	if !otherIsMap || m.Size() != otherAsMap.Size() {
+		return false
+	}
+	for _, entry := range m.Entries() {
+		otherEntry := otherAsMap.root.find(ctx, entry.Key, entry.hash, 0)
+		if otherEntry == nil || !entry.Value.Equals(ctx, otherEntry.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+//Get returns the value for the key, or nil if the map doesn't contain it
+func (m *Map) Get(ctx *Context, key *Value) *Value {
+	entry := m.root.find(ctx, key, key.HashCode(ctx), 0)
+	if entry == nil {
+		return nil
+	}
+	return entry.Value
+}
+
+func (m *Map) ContainsKey(ctx *Context, key *Value) bool {
+	return m.root.find(ctx, key, key.HashCode(ctx), 0) != nil
+}
+
+//Put returns a map with the entry added. If the key is already present, its value is replaced without changing its position
+func (m *Map) Put(ctx *Context, key *Value, value *Value) *Map {
+	hash := key.HashCode(ctx)
+	existing := m.root.find(ctx, key, hash, 0)
+	if existing != nil {
+		entry := &Entry{
+			Key:      existing.Key,
+			Value:    value,
+			hash:     hash,
+			position: existing.position,
+		}
+		return &Map{
+			MapType: m.MapType,
+			root:    m.root.put(ctx, entry, 0),
+			order:   m.order.set(entry.position, entry),
+			size:    m.size,
+		}
+	}
+	entry := &Entry{
+		Key:      key,
+		Value:    value,
+		hash:     hash,
+		position: m.order.size,
 	}
 	return &Map{
-		MapType:  mapType,
-		Elements: elements,
+		MapType: m.MapType,
+		root:    m.root.put(ctx, entry, 0),
+		order:   m.order.append(entry),
+		size:    m.size + 1,
+	}
+}
+
+//Remove returns a map without the entry for the key, and the removed value, or nil if there was no such entry
+func (m *Map) Remove(ctx *Context, key *Value) (*Map, *Value) {
+	root, removed := m.root.remove(ctx, key, key.HashCode(ctx), 0)
+	if removed == nil {
+		return m, nil
+	}
+	result := &Map{
+		MapType: m.MapType,
+		root:    root,
+		order:   m.order.set(removed.position, nil),
+		size:    m.size - 1,
+	}
+	gaps := result.order.size - result.size
+	if gaps > vectorWidth && gaps > result.size {
+		result = result.compact(ctx)
 	}
+	return result, removed.Value
+}
+
+//compact rebuilds the map without the gaps left by removed entries
+func (m *Map) compact(ctx *Context) *Map {
+	compacted := NewMap(m.MapType)
+	for _, entry := range m.Entries() {
+		compacted = compacted.Put(ctx, entry.Key, entry.Value)
+	}
+	return compacted
+}
+
+func (m *Map) Size() int {
+	return m.size
+}
+
+//Entries returns every entry in the map in the order they were inserted
+func (m *Map) Entries() []*Entry {
+	entries := make([]*Entry, 0, m.size)
+	m.order.forEach(func(_ int, value interface{}) bool {
+		if value != nil {
+			entries = append(entries, value.(*Entry))
+		}
+		return true
+	})
+	return entries
 }
 
 func (t *MapType) Name() string {
@@ -54,12 +171,13 @@ func (t *MapType) Accepts(otherType Type, ctx *Context) bool {
 func (m *Map) String() string {
 	builder := strings.Builder{}
 	builder.WriteRune('{')
-	for i, elem := range m.Elements {
+	entries := m.Entries()
+	for i, elem := range entries {
 		builder.WriteRune('\n')
 		builder.WriteString(elem.Key.String())
 		builder.WriteString(": ")
 		builder.WriteString(elem.Value.String())
-		if i != len(m.Elements)-1 {
+		if i != len(entries)-1 {
 			builder.WriteRune(',')
 		}
 	}
@@ -67,3 +185,133 @@ func (m *Map) String() string {
 	builder.WriteRune('}')
 	return builder.String()
 }
+
+var anyMapType = &MapType{
+	KeyType:   AnyType,
+	ValueType: AnyType,
+}
+
+func InitMaps(ctx *Context) {
+	define(ctx, "hashCode", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: AnyType,
+				},
+			},
+			ReturnType: IntType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0)
+			return NonReturningValue(IntValue(int64(this.HashCode(ctx))))
+		}),
+	})
+
+	define(ctx, "put", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyMapType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "value",
+					Type:     AnyType,
+					Position: 2,
+				},
+			},
+			ReturnType: anyMapType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Map)
+			key := ctx.FindParameter(1)
+			value := ctx.FindParameter(2)
+			if !this.MapType.KeyType.Accepts(key.Type, ctx) {
+				panic("Cannot use key " + key.String() + " of type " + key.Type.Name() + " in map of type " + this.MapType.Name())
+			}
+			if !this.MapType.ValueType.Accepts(value.Type, ctx) {
+				panic("Cannot use value " + value.String() + " of type " + value.Type.Name() + " in map of type " + this.MapType.Name())
+			}
+			return NonReturningValue(NewValue(this.MapType, this.Put(ctx, key, value)))
+		}),
+	})
+
+	define(ctx, "remove", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyMapType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: anyMapType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Map)
+			removed, _ := this.Remove(ctx, ctx.FindParameter(1))
+			return NonReturningValue(NewValue(this.MapType, removed))
+		}),
+	})
+
+	define(ctx, "containsKey", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyMapType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Map)
+			return NonReturningValue(BooleanValue(this.ContainsKey(ctx, ctx.FindParameter(1))))
+		}),
+	})
+
+	define(ctx, "getOrDefault", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyMapType,
+				},
+				{
+					Name:     "key",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "default",
+					Type:     AnyType,
+					Position: 2,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Map)
+			value := this.Get(ctx, ctx.FindParameter(1))
+			if value == nil {
+				return NonReturningValue(ctx.FindParameter(2))
+			}
+			return NonReturningValue(value)
+		}),
+	})
+}
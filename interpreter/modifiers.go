@@ -4,4 +4,5 @@ const (
 	Mut        = 1
 	Lazy       = 2
 	Observable = 4
+	Restricted = 8
 )
@@ -0,0 +1,51 @@
+package interpreter
+
+//Native modules are implemented in Go rather than Elara. They are loaded when they are imported, eg import elara/regex
+var nativeModules = map[string]func(ctx *Context){
+	"elara/regex": InitRegex,
+	"elara/json":  InitJSON,
+	"elara/fs":    InitFS,
+	"elara/http":  InitHTTP,
+}
+
+//nativeModule creates a context for the native module with the given namespace, or returns nil if there is no such module
+func (g *Global) nativeModule(namespace string) *Context {
+	init, exists := nativeModules[namespace]
+	if !exists {
+		return nil
+	}
+	module := g.NewContext(false)
+	module.namespace = namespace
+	module.name = namespace
+	init(module)
+	return module
+}
+
+//NewNativeStructType creates a struct type for values created by natives, such as regex matches
+func NewNativeStructType(name string, namespace string, properties []Property) *StructType {
+	propertyPositions := make(map[string]int, len(properties))
+	for i, property := range properties {
+		propertyPositions[property.Name] = i
+	}
+	return &StructType{
+		TypeName:          name,
+		Properties:        properties,
+		propertyPositions: propertyPositions,
+		namespace:         namespace,
+	}
+}
+
+//NewInstanceValue creates an instance of a struct, with values given in the same order as the struct's properties
+func NewInstanceValue(structType *StructType, values ...*Value) *Value {
+	if len(values) != len(structType.Properties) {
+		panic("Wrong number of values for struct " + structType.TypeName)
+	}
+	valueMap := make(map[string]*Value, len(values))
+	for i, property := range structType.Properties {
+		valueMap[property.Name] = values[i]
+	}
+	return NewValue(structType, &Instance{
+		Type:   structType,
+		Values: valueMap,
+	})
+}
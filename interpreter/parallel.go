@@ -0,0 +1,233 @@
+package interpreter
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+//The parallel collection functions split their work between a pool of goroutines, with one for each CPU.
+//Their functions have to be pure: they're checked with checkPure before anything runs, and the workers' contexts refuse to reassign shared variables
+//or call natives with side effects. The caller keeps hold of the Global while the workers run, so nothing else can change the variables that they read.
+
+//parallelEach calls work with every index from 0 up to size on a pool of workers, each with its own context.
+//Indices are handed out in order, and once one fails the later ones are skipped,
+//so the failure that is passed on is the one that calling work for every index in order would have stopped at.
+func parallelEach(ctx *Context, size int, work func(worker *Context, index int)) {
+	if ctx.parallel {
+		//Already running on a worker, which every CPU is busy with, so starting more wouldn't help
+		for i := 0; i < size; i++ {
+			work(ctx, i)
+		}
+		return
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > size {
+		workers = size
+	}
+	next := int64(-1)
+	firstFailure := int64(size) //The lowest index that has failed, size if none have, or -1 if one exited
+	var failureMutex sync.Mutex
+	var failure interface{}
+
+	run := func(worker *Context, index int) {
+		defer func() {
+			if r := recover(); r != nil {
+				failureMutex.Lock()
+				defer failureMutex.Unlock()
+				if _, isExit := r.(*Exit); isExit {
+					//An exit stops the script whichever element it came from, so it replaces any other failure and stops every worker
+					atomic.StoreInt64(&firstFailure, -1)
+					failure = r
+				} else if int64(index) < atomic.LoadInt64(&firstFailure) {
+					atomic.StoreInt64(&firstFailure, int64(index))
+					failure = r
+				}
+			}
+		}()
+		work(worker, index)
+	}
+
+	scopes := make([]*Context, workers)
+	var wait sync.WaitGroup
+	for i := range scopes {
+		scope := ctx.EnterScope("parallel", nil, 0)
+		scope.limits = ctx.limits.child(ctx.limits.goContext())
+		scope.parallel = true
+		scopes[i] = scope
+
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				index := atomic.AddInt64(&next, 1)
+				if index >= int64(size) || index > atomic.LoadInt64(&firstFailure) {
+					return
+				}
+				run(scope, int(index))
+			}
+		}()
+	}
+	wait.Wait()
+
+	for _, scope := range scopes {
+		scope.Cleanup()
+	}
+	if failure != nil {
+		panic(failure)
+	}
+}
+
+//InitParallel defines parallelMap, parallelFilter and parallelFold, which work like map, filter and fold but share the work between every CPU
+func InitParallel(ctx *Context) {
+	define(ctx, "parallelMap", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "transform",
+					Type:     functionTypeOf(AnyType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			transform := ctx.FindParameter(1).Value.(*Function)
+			checkPure(ctx, "parallelMap", transform)
+			results := make([]*Value, this.Size())
+			parallelEach(ctx, this.Size(), func(worker *Context, i int) {
+				results[i] = transform.Exec(worker, []*Value{this.Get(i)})
+			})
+			return NonReturningValue(CollectionValue(resultElementType(ctx, transform, results), results))
+		}),
+	})
+
+	define(ctx, "parallelFilter", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "predicate",
+					Type:     functionTypeOf(BooleanType, AnyType),
+					Position: 1,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			predicate := ctx.FindParameter(1).Value.(*Function)
+			checkPure(ctx, "parallelFilter", predicate)
+			keep := make([]bool, this.Size())
+			parallelEach(ctx, this.Size(), func(worker *Context, i int) {
+				keep[i] = booleanResult(predicate, worker, this.Get(i))
+			})
+			results := make([]*Value, 0)
+			this.ForEach(func(i int, element *Value) bool {
+				if keep[i] {
+					results = append(results, element)
+				}
+				return true
+			})
+			return NonReturningValue(CollectionValue(this.ElementType, results))
+		}),
+	})
+
+	//parallelFold folds a chunk of the collection on each worker, starting each from initial, and then combines the chunks' results in order.
+	//This gives the same result as fold as long as operation and combine are associative, and initial doesn't change what it is combined with, such as 0 for +
+	parallelFold := func(ctx *Context, operation *Function, combine *Function) *Value {
+		this := collectionParameter(ctx, 0)
+		initial := ctx.FindParameter(1)
+		checkPure(ctx, "parallelFold", operation)
+		checkPure(ctx, "parallelFold", combine)
+		size := this.Size()
+		if size == 0 {
+			return initial
+		}
+
+		chunks := runtime.GOMAXPROCS(0)
+		if chunks > size {
+			chunks = size
+		}
+		results := make([]*Value, chunks)
+		parallelEach(ctx, chunks, func(worker *Context, chunk int) {
+			accumulator := initial
+			for i := chunk * size / chunks; i < (chunk+1)*size/chunks; i++ {
+				accumulator = operation.Exec(worker, []*Value{accumulator, this.Get(i)})
+			}
+			results[chunk] = accumulator
+		})
+
+		result := results[0]
+		for _, chunkResult := range results[1:] {
+			result = combine.Exec(ctx, []*Value{result, chunkResult})
+		}
+		return result
+	}
+
+	define(ctx, "parallelFold", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "initial",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "operation",
+					Type:     functionTypeOf(AnyType, AnyType, AnyType),
+					Position: 2,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			operation := ctx.FindParameter(2).Value.(*Function)
+			return NonReturningValue(parallelFold(ctx, operation, operation))
+		}),
+	})
+
+	//With a separate combine function, the accumulator can be a different type to the elements, eg counting characters with parallelFold(0, (Int count, String s) => count + s.size, (Int a, Int b) => a + b)
+	define(ctx, "parallelFold", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+				{
+					Name:     "initial",
+					Type:     AnyType,
+					Position: 1,
+				},
+				{
+					Name:     "operation",
+					Type:     functionTypeOf(AnyType, AnyType, AnyType),
+					Position: 2,
+				},
+				{
+					Name:     "combine",
+					Type:     functionTypeOf(AnyType, AnyType, AnyType),
+					Position: 3,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			return NonReturningValue(parallelFold(ctx, ctx.FindParameter(2).Value.(*Function), ctx.FindParameter(3).Value.(*Function)))
+		}),
+	})
+}
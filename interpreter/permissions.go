@@ -0,0 +1,173 @@
+package interpreter
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//AllowAll can be given as a host, path or variable name to allow every host, path or variable
+const AllowAll = "*"
+
+//Permissions are the capabilities that a script has to act outside of the interpreter.
+//Every native that reads files, writes files, reads environment variables or uses the network checks them first,
+//and stops the script with a PermissionError if the capability is missing.
+//Contexts that don't belong to an Interpreter have no permissions at all.
+type Permissions struct {
+	Net   []string //Hosts that can be connected to, eg "example.com", or "example.com:8080" to only allow one port. Servers need "0.0.0.0" or "0.0.0.0:port"
+	Read  []string //Files and directories that can be read, including everything inside the directories
+	Write []string //Files and directories that can be written to or deleted, including everything inside the directories
+	Env   []string //Environment variables that can be read
+}
+
+//AllPermissions allows everything, which is how scripts behave if they aren't given any Permissions
+func AllPermissions() *Permissions {
+	return &Permissions{
+		Net:   []string{AllowAll},
+		Read:  []string{AllowAll},
+		Write: []string{AllowAll},
+		Env:   []string{AllowAll},
+	}
+}
+
+//NoPermissions allows nothing, so that untrusted scripts can only compute values and use the interpreter's own streams
+func NoPermissions() *Permissions {
+	return &Permissions{}
+}
+
+//A PermissionError stops a script that tried to do something it doesn't have the permission for
+type PermissionError struct {
+	Permission string //net, read, write or env
+	Resource   string //The host, path or variable that the script tried to use
+}
+
+func (e *PermissionError) Error() string {
+	return "Permission denied: " + e.Permission + " access to " + e.Resource + " requires --allow-" + e.Permission
+}
+
+//canRead checks if path, which must be absolute, can be read
+func (p *Permissions) canRead(path string) bool {
+	return p != nil && allowsPath(p.Read, path)
+}
+
+//checkRead panics with a PermissionError unless path, which must be absolute, can be read
+func (p *Permissions) checkRead(path string) {
+	if !p.canRead(path) {
+		panic(&PermissionError{Permission: "read", Resource: path})
+	}
+}
+
+func (p *Permissions) checkWrite(path string) {
+	if p == nil || !allowsPath(p.Write, path) {
+		panic(&PermissionError{Permission: "write", Resource: path})
+	}
+}
+
+func (p *Permissions) checkEnv(name string) {
+	if p == nil || !allows(p.Env, name) {
+		panic(&PermissionError{Permission: "env", Resource: name})
+	}
+}
+
+//checkNet panics with a PermissionError unless the host of the URL can be connected to
+func (p *Permissions) checkNet(rawURL string) {
+	if err := p.netError(rawURL); err != nil {
+		panic(err)
+	}
+}
+
+//netError returns the PermissionError that checkNet would panic with, or nil if the host of the URL can be connected to
+func (p *Permissions) netError(rawURL string) *PermissionError {
+	host := rawURL
+	parsed, err := url.Parse(rawURL)
+	if err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	if p == nil || !allowsHost(p.Net, host) {
+		return &PermissionError{Permission: "net", Resource: host}
+	}
+	return nil
+}
+
+//checkListen panics with a PermissionError unless a server can listen on the port
+func (p *Permissions) checkListen(port int64) {
+	p.checkHost("0.0.0.0:" + strconv.FormatInt(port, 10))
+}
+
+func (p *Permissions) checkHost(host string) {
+	if p == nil || !allowsHost(p.Net, host) {
+		panic(&PermissionError{Permission: "net", Resource: host})
+	}
+}
+
+func allows(allowed []string, name string) bool {
+	for _, a := range allowed {
+		if a == AllowAll || a == name {
+			return true
+		}
+	}
+	return false
+}
+
+//allowsPath checks if a path is one of the allowed paths, or inside one of them.
+//Symbolic links are followed on both sides first, so that a link inside an allowed directory can't reach anything outside it.
+func allowsPath(allowed []string, path string) bool {
+	path = resolvePath(path, 0)
+	for _, a := range allowed {
+		if a == AllowAll {
+			return true
+		}
+		absolute, err := filepath.Abs(a)
+		if err != nil {
+			continue
+		}
+		absolute = resolvePath(absolute, 0)
+		if path == absolute || strings.HasPrefix(path, strings.TrimSuffix(absolute, string(os.PathSeparator))+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+//maxLinks is how many symbolic links resolvePath follows before giving up, in case they form a loop
+const maxLinks = 40
+
+//resolvePath follows the symbolic links in an absolute path. The parts of it that don't exist yet, such as a file that is about to be created, are kept as they are,
+//but a link to something that doesn't exist is still followed, as writing to it would create its target
+func resolvePath(path string, links int) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path || links > maxLinks {
+		return path
+	}
+	parent = resolvePath(parent, links)
+	joined := filepath.Join(parent, filepath.Base(path))
+	target, err := os.Readlink(joined)
+	if err != nil {
+		return joined
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(parent, target)
+	}
+	return resolvePath(target, links+1)
+}
+
+//allowsHost checks a host, which may include a port. Allowing a host without a port allows every port
+func allowsHost(allowed []string, host string) bool {
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+	}
+	for _, a := range allowed {
+		if a == AllowAll || strings.EqualFold(a, host) || strings.EqualFold(a, hostname) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"os"
+)
+
+//Exit is panicked by exit(code) to stop the script, and recovered by the Interpreter that is running it
+type Exit struct {
+	Code int
+}
+
+//InitProcess defines args, env and exit, which let a script interact with the process that is running it
+func InitProcess(ctx *Context, args []string) {
+	argsValue := stringCollectionValue(args)
+	ctx.DefineVariable(&Variable{
+		Name:    "args",
+		Mutable: false,
+		Type:    argsValue.Type,
+		Value:   argsValue,
+	})
+
+	//env returns the value of an environment variable, or Unit if it isn't set. The script needs permission to read the variable
+	defineImpure(ctx, "env", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "name",
+					Type: StringType,
+				},
+			},
+			ReturnType: AnyType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			name := stringParameter(ctx, 0)
+			ctx.permissions.checkEnv(name)
+			value, isSet := os.LookupEnv(name)
+			if !isSet {
+				return NonReturningValue(UnitValue())
+			}
+			return NonReturningValue(StringValue(value))
+		}),
+	})
+
+	defineImpure(ctx, "exit", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "code",
+					Type: IntType,
+				},
+			},
+			ReturnType: UnitType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			panic(&Exit{Code: int(ctx.FindParameter(0).Value.(int64))})
+		}),
+	})
+}
@@ -0,0 +1,217 @@
+package interpreter
+
+import "github.com/ElaraLang/elara/parser"
+
+//A purityChecker looks through a function before it is run in parallel, to find anything that would change state shared with the other workers.
+//It can only find what is known without running the function: reassigning a variable that the function didn't define,
+//using a native with side effects, setting a property, extending a type, or importing a namespace.
+//Anything that isn't known until the function runs, such as which function a receiver call will find, is checked as it runs instead,
+//as parallel contexts refuse to do any of these things.
+type purityChecker struct {
+	ctx     *Context
+	checked map[*Function]bool //Functions that have been checked, or are being checked, so that recursive functions terminate
+}
+
+//checkPure panics if the function given to the named parallel function is known to have side effects
+func checkPure(ctx *Context, caller string, function *Function) {
+	checker := &purityChecker{
+		ctx:     ctx,
+		checked: map[*Function]bool{},
+	}
+	if reason := checker.function(function); reason != "" {
+		panic(caller + " only accepts pure functions, but the function " + reason)
+	}
+}
+
+//localNames are the variables that a function defines itself, which it is allowed to reassign
+type localNames struct {
+	names  map[string]bool
+	parent *localNames
+}
+
+func (l *localNames) child() *localNames {
+	return &localNames{
+		names:  map[string]bool{},
+		parent: l,
+	}
+}
+
+func (l *localNames) contains(name string) bool {
+	for names := l; names != nil; names = names.parent {
+		if names.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+//function returns why the function isn't pure, or an empty string if nothing impure was found
+func (p *purityChecker) function(function *Function) string {
+	if p.checked[function] {
+		return ""
+	}
+	p.checked[function] = true
+	if function.impure {
+		return "has side effects"
+	}
+	if _, isNative := function.Body.(*AbstractCommand); isNative {
+		return ""
+	}
+
+	locals := &localNames{names: map[string]bool{}}
+	for _, parameter := range function.Signature.Parameters {
+		locals.names[parameter.Name] = true
+	}
+	resolving := function.context
+	if resolving == nil {
+		resolving = p.ctx
+	}
+	return p.command(function.Body, resolving, locals)
+}
+
+func (p *purityChecker) commands(resolving *Context, locals *localNames, commands ...Command) string {
+	for _, command := range commands {
+		if reason := p.command(command, resolving, locals); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (p *purityChecker) command(command Command, resolving *Context, locals *localNames) string {
+	switch c := command.(type) {
+	case *DefineVarCommand:
+		locals.names[c.Name] = true //Defined first, so that recursive functions can refer to themselves
+		return p.command(c.value, resolving, locals)
+	case *DestructuringCommand:
+		definePatternNames(c.pattern, locals)
+		return p.command(c.value, resolving, locals)
+	case *destructureParametersCommand:
+		for _, pattern := range c.patterns {
+			definePatternNames(pattern, locals)
+		}
+		return p.command(c.body, resolving, locals)
+	case *AssignmentCommand:
+		if !locals.contains(c.Name) {
+			return "reassigns " + c.Name
+		}
+		return p.command(c.value, resolving, locals)
+	case *PropertyAssignmentCommand:
+		return "sets the property " + c.Name
+	case *VariableCommand:
+		if locals.contains(c.Variable) {
+			return ""
+		}
+		return p.variable(c, resolving)
+	case *InvocationCommand:
+		if reason := p.commands(resolving, locals, c.args...); reason != "" {
+			return reason
+		}
+		return p.command(c.Invoking, resolving, locals)
+	case *ContextCommand:
+		return p.command(c.receiver, resolving, locals) //The function called on the receiver depends on its type, so it is checked when it is called
+	case *FunctionLiteralCommand:
+		inner := locals.child()
+		for _, parameter := range c.parameters {
+			inner.names[parameter.Name] = true
+		}
+		return p.command(c.body, resolving, inner)
+	case *BlockCommand:
+		inner := locals.child()
+		for _, line := range c.lines {
+			if reason := p.command(*line, resolving, inner); reason != "" {
+				return reason
+			}
+		}
+		return ""
+	case *IfElseCommand:
+		return p.commands(resolving, locals, c.condition, c.ifBranch, c.elseBranch)
+	case *IfElseExpressionCommand:
+		if reason := p.command(c.condition, resolving, locals); reason != "" {
+			return reason
+		}
+		if reason := p.commands(resolving, locals.child(), c.ifBranch...); reason != "" {
+			return reason
+		}
+		if reason := p.command(c.ifResult, resolving, locals.child()); reason != "" {
+			return reason
+		}
+		if reason := p.commands(resolving, locals.child(), c.elseBranch...); reason != "" {
+			return reason
+		}
+		return p.command(c.elseResult, resolving, locals.child())
+	case *WhileCommand:
+		return p.commands(resolving, locals.child(), c.condition, c.body)
+	case *ForCommand:
+		if reason := p.command(c.collection, resolving, locals); reason != "" {
+			return reason
+		}
+		inner := locals.child()
+		definePatternNames(c.pattern, inner)
+		return p.command(c.body, resolving, inner)
+	case *CollectionCommand:
+		return p.commands(resolving, locals, c.Elements...)
+	case *TupleCommand:
+		return p.commands(resolving, locals, c.Elements...)
+	case *SetCommand:
+		return p.commands(resolving, locals, c.Elements...)
+	case *MapCommand:
+		for _, entry := range c.entries {
+			if reason := p.commands(resolving, locals, entry.key, entry.value); reason != "" {
+				return reason
+			}
+		}
+		return ""
+	case *AccessCommand:
+		return p.commands(resolving, locals, c.checking, c.index)
+	case *RangeCommand:
+		return p.commands(resolving, locals, c.start, c.end)
+	case *TypeCheckCommand:
+		return p.command(c.expression, resolving, locals)
+	case *ReturnCommand:
+		return p.command(c.returning, resolving, locals)
+	case *NotEqualsCommand:
+		return p.command(c.equals, resolving, locals)
+	case *BinaryOperatorCommand:
+		return p.commands(resolving, locals, c.lhs, c.rhs)
+	case *ExtendCommand:
+		return "extends " + c.Type
+	case *ImportCommand:
+		return "imports a namespace"
+	case *NamespaceCommand:
+		return "declares a namespace"
+	case *LiteralCommand, *JumpCommand, *TypeCommand, *StructDefCommand:
+		return "" //Type and struct definitions only change the function's own scope
+	}
+	return "contains something that can't be checked" //So that new kinds of command are assumed to have side effects until they are checked here
+}
+
+//variable checks the function that a variable refers to, if it does.
+//Variables are found through the function's own context and through the caller, as either could be the one that is used
+func (p *purityChecker) variable(c *VariableCommand, resolving *Context) string {
+	for _, context := range []*Context{resolving, p.ctx} {
+		variable, _ := context.FindVariableMaxDepth(c.hash, -1)
+		if variable == nil || variable.Lazy != nil {
+			continue //Lazy bindings are checked when they are evaluated, so that using them doesn't evaluate them early
+		}
+		function, isFunction := variable.Value.Value.(*Function)
+		if !isFunction {
+			continue
+		}
+		if reason := p.function(function); reason != "" {
+			return "uses " + c.Variable + ", which " + reason
+		}
+	}
+	return ""
+}
+
+func definePatternNames(pattern parser.Pattern, locals *localNames) {
+	switch t := pattern.(type) {
+	case parser.IdentifierPattern:
+		locals.names[t.Identifier] = true
+	case parser.TuplePattern:
+		for _, element := range t.Elements {
+			definePatternNames(element, locals)
+		}
+	}
+}
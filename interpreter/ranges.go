@@ -0,0 +1,49 @@
+package interpreter
+
+import "strconv"
+
+var RangeType = NewEmptyType("Range")
+
+//A Range is every integer from Start up to, but not including, End.
+//Ranges don't store their elements, so iterating over a large range is cheap.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func RangeValue(start int64, end int64) *Value {
+	return NewValue(RangeType, &Range{
+		Start: start,
+		End:   end,
+	})
+}
+
+func (r *Range) Size() int64 {
+	if r.End < r.Start {
+		return 0
+	}
+	return r.End - r.Start
+}
+
+func (r *Range) String() string {
+	return strconv.FormatInt(r.Start, 10) + ".." + strconv.FormatInt(r.End, 10)
+}
+
+func (r *Range) Equals(other *Value) bool {
+	otherRange, isRange := other.Value.(*Range)
+	return isRange && r.Start == otherRange.Start && r.End == otherRange.End
+}
+
+type RangeCommand struct {
+	start Command
+	end   Command
+}
+
+func (c *RangeCommand) Exec(ctx *Context) *ReturnedValue {
+	start, startIsInt := c.start.Exec(ctx).Unwrap().Value.(int64)
+	end, endIsInt := c.end.Exec(ctx).Unwrap().Value.(int64)
+	if !startIsInt || !endIsInt {
+		panic("Range bounds must be integers")
+	}
+	return NonReturningValue(RangeValue(start, end))
+}
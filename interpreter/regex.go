@@ -0,0 +1,186 @@
+package interpreter
+
+import (
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+var RegexType = NewEmptyType("Regex")
+
+// Regex is a compiled regular expression, using Go's RE2 syntax.
+// Regexes can be compiled with compile from elara/regex, or written as literals, eg re"[0-9]+", which are checked when the script is parsed.
+type Regex struct {
+	pattern *regexp.Regexp
+	whole   *regexp.Regexp //The same pattern, but only matching the whole input
+}
+
+func NewRegex(pattern string) (*Regex, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Regex{
+		pattern: compiled,
+		whole:   regexp.MustCompile(`^(?:` + pattern + `)$`),
+	}, nil
+}
+
+func RegexValue(regex *Regex) *Value {
+	return NewValue(RegexType, regex)
+}
+
+func (r *Regex) String() string {
+	return `re"` + r.pattern.String() + `"`
+}
+
+// A Match is a single match of a regex. Positions are rune indexes, in the same way as strings are indexed
+var matchType = NewNativeStructType("Match", "elara/regex", []Property{
+	{Name: "value", Type: StringType},
+	{Name: "start", Type: IntType},
+	{Name: "end", Type: IntType},
+	{Name: "groups", Type: NewCollectionTypeOf(StringType)},                     //Every capture group, not including the whole match
+	{Name: "named", Type: &MapType{KeyType: StringType, ValueType: StringType}}, //Named capture groups that took part in the match
+})
+
+// runeCounter converts byte offsets into rune indexes. Offsets must be given in increasing order
+type runeCounter struct {
+	input  string
+	offset int
+	runes  int
+}
+
+func (c *runeCounter) runeIndex(offset int) int {
+	c.runes += utf8.RuneCountInString(c.input[c.offset:offset])
+	c.offset = offset
+	return c.runes
+}
+
+// matchValues converts the byte offsets from Go's FindAllStringSubmatchIndex into Match instances
+func (r *Regex) matchValues(ctx *Context, input string, indexes [][]int) []*Value {
+	counter := &runeCounter{input: input}
+	names := r.pattern.SubexpNames()
+	matches := make([]*Value, len(indexes))
+	for i, index := range indexes {
+		groups := make([]*Value, 0, len(index)/2-1)
+		named := NewMap(&MapType{KeyType: StringType, ValueType: StringType})
+		for group := 1; group < len(index)/2; group++ {
+			start, end := index[group*2], index[group*2+1]
+			if start < 0 {
+				groups = append(groups, StringValue("")) //The group didn't take part in the match
+				continue
+			}
+			groupValue := StringValue(input[start:end])
+			groups = append(groups, groupValue)
+			if names[group] != "" {
+				named = named.Put(ctx, StringValue(names[group]), groupValue)
+			}
+		}
+		matches[i] = NewInstanceValue(matchType,
+			StringValue(input[index[0]:index[1]]),
+			IntValue(int64(counter.runeIndex(index[0]))),
+			IntValue(int64(counter.runeIndex(index[1]))),
+			CollectionValue(StringType, groups),
+			NewValue(named.MapType, named),
+		)
+	}
+	return matches
+}
+
+func InitRegex(ctx *Context) {
+	ctx.types[matchType.TypeName] = matchType
+
+	define(ctx, "compile", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "pattern",
+					Type: StringType,
+				},
+			},
+			ReturnType: orError(RegexType),
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			regex, err := NewRegex(stringParameter(ctx, 0))
+			if err != nil {
+				return NonReturningValue(ErrorValue(err.Error()))
+			}
+			return NonReturningValue(RegexValue(regex))
+		}),
+	})
+
+	regexFunction := func(name string, returnType Type, parameterTypes []Type, body func(ctx *Context, this *Regex, input string) *Value) {
+		parameters := []Parameter{
+			{
+				Name: "this",
+				Type: RegexType,
+			},
+			{
+				Name:     "input",
+				Type:     StringType,
+				Position: 1,
+			},
+		}
+		for i, parameterType := range parameterTypes {
+			parameters = append(parameters, Parameter{
+				Name:     "arg" + strconv.Itoa(i),
+				Type:     parameterType,
+				Position: uint(i + 2),
+			})
+		}
+		define(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: parameters,
+				ReturnType: returnType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				this := ctx.FindParameter(0).Value.(*Regex)
+				return NonReturningValue(body(ctx, this, stringParameter(ctx, 1)))
+			}),
+		})
+	}
+
+	//matches checks if the whole input matches, whereas find can match any part of it
+	regexFunction("matches", BooleanType, nil, func(ctx *Context, this *Regex, input string) *Value {
+		return BooleanValue(this.whole.MatchString(input))
+	})
+
+	//find returns the first match, or Unit if there isn't one
+	regexFunction("find", AnyType, nil, func(ctx *Context, this *Regex, input string) *Value {
+		index := this.pattern.FindStringSubmatchIndex(input)
+		if index == nil {
+			return UnitValue()
+		}
+		return this.matchValues(ctx, input, [][]int{index})[0]
+	})
+
+	regexFunction("findAll", NewCollectionTypeOf(matchType), nil, func(ctx *Context, this *Regex, input string) *Value {
+		matches := this.matchValues(ctx, input, this.pattern.FindAllStringSubmatchIndex(input, -1))
+		return CollectionValue(matchType, matches)
+	})
+
+	//Replacing with a string expands $1, ${name} etc to the matching capture groups
+	regexFunction("replace", StringType, []Type{StringType}, func(ctx *Context, this *Regex, input string) *Value {
+		return StringValue(this.pattern.ReplaceAllString(input, stringParameter(ctx, 2)))
+	})
+
+	//Replacing with a function calls it with every Match, and replaces the match with the String that it returns
+	regexFunction("replace", StringType, []Type{functionTypeOf(StringType, matchType)}, func(ctx *Context, this *Regex, input string) *Value {
+		replacement := ctx.FindParameter(2).Value.(*Function)
+		indexes := this.pattern.FindAllStringSubmatchIndex(input, -1)
+		matches := this.matchValues(ctx, input, indexes)
+		builder := make([]byte, 0, len(input))
+		previous := 0
+		for i, index := range indexes {
+			builder = append(builder, input[previous:index[0]]...)
+			builder = append(builder, stringOf(replacement.Exec(ctx, []*Value{matches[i]}))...)
+			previous = index[1]
+		}
+		builder = append(builder, input[previous:]...)
+		return StringValue(string(builder))
+	})
+
+	regexFunction("split", NewCollectionTypeOf(StringType), nil, func(ctx *Context, this *Regex, input string) *Value {
+		return stringCollectionValue(this.pattern.Split(input, -1))
+	})
+}
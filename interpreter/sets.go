@@ -0,0 +1,311 @@
+package interpreter
+
+import "strings"
+
+//A Set is an unordered collection without duplicates. Elements are compared in the same way as map keys,
+//so a struct with hashCode and equals extensions behaves the same in a set as it does in a map.
+//Sets are backed by a persistent map, so they are also immutable and iterate in insertion order.
+type Set struct {
+	SetType *SetType
+	entries *Map
+}
+
+type SetType struct {
+	ElementType Type
+}
+
+func NewSetTypeOf(elementType Type) *SetType {
+	return &SetType{
+		ElementType: elementType,
+	}
+}
+
+func (t *SetType) Name() string {
+	return "Set<" + t.ElementType.Name() + ">" //Eg Set<Int>
+}
+
+func (t *SetType) Accepts(otherType Type, ctx *Context) bool {
+	otherSet, ok := otherType.(*SetType)
+	if !ok {
+		return false
+	}
+	return t.ElementType.Accepts(otherSet.ElementType, ctx)
+}
+
+var anySetType = NewSetTypeOf(AnyType)
+
+func NewSet(setType *SetType) *Set {
+	return &Set{
+		SetType: setType,
+		entries: NewMap(anyMapType),
+	}
+}
+
+//SetOf creates a set of the elements, typed by their common type. Duplicates are ignored, keeping the first occurrence
+func SetOf(ctx *Context, elements []*Value) *Set {
+	set := NewSet(NewSetTypeOf(commonElementType(ctx, elements)))
+	for _, element := range elements {
+		set = set.Add(ctx, element)
+	}
+	return set
+}
+
+func SetValue(set *Set) *Value {
+	return NewValue(set.SetType, set)
+}
+
+func (s *Set) Contains(ctx *Context, element *Value) bool {
+	return s.entries.ContainsKey(ctx, element)
+}
+
+//Add returns a set with the element added, or this set if it already contains an equal element
+func (s *Set) Add(ctx *Context, element *Value) *Set {
+	if s.Contains(ctx, element) {
+		return s
+	}
+	return &Set{
+		SetType: s.SetType,
+		entries: s.entries.Put(ctx, element, UnitValue()),
+	}
+}
+
+func (s *Set) Remove(ctx *Context, element *Value) *Set {
+	entries, removed := s.entries.Remove(ctx, element)
+	if removed == nil {
+		return s
+	}
+	return &Set{
+		SetType: s.SetType,
+		entries: entries,
+	}
+}
+
+func (s *Set) Size() int {
+	return s.entries.Size()
+}
+
+//Elements returns every element in the order they were added
+func (s *Set) Elements() []*Value {
+	entries := s.entries.Entries()
+	elements := make([]*Value, len(entries))
+	for i, entry := range entries {
+		elements[i] = entry.Key
+	}
+	return elements
+}
+
+func (s *Set) Union(ctx *Context, other *Set) *Set {
+	result := &Set{
+		SetType: NewSetTypeOf(CommonType([]Type{s.SetType.ElementType, other.SetType.ElementType}, ctx)),
+		entries: s.entries,
+	}
+	for _, element := range other.Elements() {
+		result = result.Add(ctx, element)
+	}
+	return result
+}
+
+func (s *Set) Intersect(ctx *Context, other *Set) *Set {
+	result := NewSet(s.SetType)
+	for _, element := range s.Elements() {
+		if other.Contains(ctx, element) {
+			result = result.Add(ctx, element)
+		}
+	}
+	return result
+}
+
+func (s *Set) Difference(ctx *Context, other *Set) *Set {
+	result := s
+	for _, element := range other.Elements() {
+		result = result.Remove(ctx, element)
+	}
+	return result
+}
+
+//Equals checks that both sets have the same elements, regardless of their order
+func (s *Set) Equals(ctx *Context, other *Value) bool {
+	otherAsSet, otherIsSet := other.Value.(*Set)
+	if !otherIsSet || s.Size() != otherAsSet.Size() {
+		return false
+	}
+	for _, element := range s.Elements() {
+		if !otherAsSet.Contains(ctx, element) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Set) String() string {
+	elements := s.Elements()
+	elemStrings := make([]string, len(elements))
+	for i, element := range elements {
+		elemStrings[i] = element.String()
+	}
+	return "#{" + strings.Join(elemStrings, ", ") + "}"
+}
+
+type SetCommand struct {
+	Elements []Command
+}
+
+func (c *SetCommand) Exec(ctx *Context) *ReturnedValue {
+	elements := make([]*Value, len(c.Elements))
+	for i, element := range c.Elements {
+		elements[i] = element.Exec(ctx).Unwrap()
+	}
+	return NonReturningValue(SetValue(SetOf(ctx, elements)))
+}
+
+func InitSets(ctx *Context) {
+	define(ctx, "toSet", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyCollectionType,
+				},
+			},
+			ReturnType: anySetType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := collectionParameter(ctx, 0)
+			set := NewSet(NewSetTypeOf(this.ElementType))
+			for _, element := range this.Elements() {
+				set = set.Add(ctx, element)
+			}
+			return NonReturningValue(SetValue(set))
+		}),
+	})
+
+	define(ctx, "keySet", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anyMapType,
+				},
+			},
+			ReturnType: anySetType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Map)
+			set := NewSet(NewSetTypeOf(this.MapType.KeyType))
+			for _, entry := range this.Entries() {
+				set = set.Add(ctx, entry.Key)
+			}
+			return NonReturningValue(SetValue(set))
+		}),
+	})
+
+	define(ctx, "toList", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anySetType,
+				},
+			},
+			ReturnType: anyCollectionType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Set)
+			return NonReturningValue(CollectionValue(this.SetType.ElementType, this.Elements()))
+		}),
+	})
+
+	define(ctx, "contains", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anySetType,
+				},
+				{
+					Name:     "element",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: BooleanType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Set)
+			return NonReturningValue(BooleanValue(this.Contains(ctx, ctx.FindParameter(1))))
+		}),
+	})
+
+	define(ctx, "add", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anySetType,
+				},
+				{
+					Name:     "element",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: anySetType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Set)
+			element := ctx.FindParameter(1)
+			if !this.SetType.ElementType.Accepts(element.Type, ctx) {
+				panic("Cannot add " + element.String() + " of type " + element.Type.Name() + " to set of type " + this.SetType.Name())
+			}
+			return NonReturningValue(SetValue(this.Add(ctx, element)))
+		}),
+	})
+
+	define(ctx, "remove", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: anySetType,
+				},
+				{
+					Name:     "element",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: anySetType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*Set)
+			return NonReturningValue(SetValue(this.Remove(ctx, ctx.FindParameter(1))))
+		}),
+	})
+
+	setOperation := func(name string, operation func(this *Set, ctx *Context, other *Set) *Set) {
+		define(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: []Parameter{
+					{
+						Name: "this",
+						Type: anySetType,
+					},
+					{
+						Name:     "other",
+						Type:     anySetType,
+						Position: 1,
+					},
+				},
+				ReturnType: anySetType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				this := ctx.FindParameter(0).Value.(*Set)
+				other := ctx.FindParameter(1).Value.(*Set)
+				return NonReturningValue(SetValue(operation(this, ctx, other)))
+			}),
+		})
+	}
+	setOperation("union", (*Set).Union)
+	setOperation("intersect", (*Set).Intersect)
+	setOperation("difference", (*Set).Difference)
+}
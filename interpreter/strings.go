@@ -0,0 +1,241 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//Strings are stored as Go strings, and so are UTF-8 encoded. However, everything that Elara code can see works in runes:
+//a string's size is the number of runes in it, indexes count runes rather than bytes, and iterating gives every rune as a Char.
+//Strings are typed as a collection of chars, so any [Char] can be used as a String, and converted with stringOf.
+
+//stringOf gets the Go string for a String value, which may be a Go string or a collection of chars
+func stringOf(value *Value) string {
+	switch t := value.Value.(type) {
+	case string:
+		return t
+	case *Collection:
+		return t.elemsAsString()
+	}
+	panic("Cannot use value of type " + value.Type.Name() + " as a String")
+}
+
+func stringParameter(ctx *Context, position uint) string {
+	return stringOf(ctx.FindParameter(position))
+}
+
+//charsOf splits a string into a collection of its chars
+func charsOf(value string) *Collection {
+	chars := make([]*Value, 0, len(value))
+	for _, char := range value {
+		chars = append(chars, CharValue(char))
+	}
+	return NewCollection(CharType, chars)
+}
+
+//Finding a rune by its index means counting the runes before it, so indexing a string takes time proportional to the index.
+//Going through every char of a long string should be done by iterating over it, which is linear, rather than by indexing it in a loop.
+
+func stringLength(value string) int {
+	return utf8.RuneCountInString(value)
+}
+
+//runeOffset converts a rune index into a byte offset into the string. The index may be equal to the length of the string
+func runeOffset(value string, index int) int {
+	if index >= 0 {
+		runes := 0
+		for offset := range value {
+			if runes == index {
+				return offset
+			}
+			runes++
+		}
+		if runes == index {
+			return len(value)
+		}
+	}
+	panic(fmt.Sprintf("Index %d out of bounds for string of length %d", index, stringLength(value)))
+}
+
+func runeAt(value string, index int) rune {
+	offset := runeOffset(value, index)
+	if offset == len(value) {
+		panic(fmt.Sprintf("Index %d out of bounds for string of length %d", index, stringLength(value)))
+	}
+	char, _ := utf8.DecodeRuneInString(value[offset:])
+	return char
+}
+
+func stringCollectionValue(values []string) *Value {
+	elements := make([]*Value, len(values))
+	for i, value := range values {
+		elements[i] = StringValue(value)
+	}
+	return CollectionValue(StringType, elements)
+}
+
+var StringBuilderType = NewEmptyType("StringBuilder")
+
+//A StringBuilder is a mutable string, so that building a string in a loop is linear rather than copying the whole string every time
+type StringBuilder struct {
+	builder strings.Builder
+	length  int
+}
+
+func (b *StringBuilder) String() string {
+	return b.builder.String()
+}
+
+func InitStrings(ctx *Context) {
+	stringFunction := func(name string, returnType Type, parameterTypes []Type, body func(ctx *Context, this string) *Value) {
+		parameters := []Parameter{
+			{
+				Name: "this",
+				Type: StringType,
+			},
+		}
+		for i, parameterType := range parameterTypes {
+			parameters = append(parameters, Parameter{
+				Name:     "arg" + strconv.Itoa(i),
+				Type:     parameterType,
+				Position: uint(i + 1),
+			})
+		}
+		define(ctx, name, &Function{
+			Signature: Signature{
+				Parameters: parameters,
+				ReturnType: returnType,
+			},
+			Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+				return NonReturningValue(body(ctx, stringParameter(ctx, 0)))
+			}),
+		})
+	}
+
+	stringFunction("length", IntType, nil, func(ctx *Context, this string) *Value {
+		return IntValue(int64(stringLength(this)))
+	})
+
+	//substring takes the runes from start up to, but not including, end
+	stringFunction("substring", StringType, []Type{IntType, IntType}, func(ctx *Context, this string) *Value {
+		start := int(ctx.FindParameter(1).Value.(int64))
+		end := int(ctx.FindParameter(2).Value.(int64))
+		if end < start {
+			panic(fmt.Sprintf("Substring end %d is before start %d", end, start))
+		}
+		return StringValue(this[runeOffset(this, start):runeOffset(this, end)])
+	})
+
+	stringFunction("split", NewCollectionTypeOf(StringType), []Type{StringType}, func(ctx *Context, this string) *Value {
+		return stringCollectionValue(strings.Split(this, stringParameter(ctx, 1)))
+	})
+
+	stringFunction("trim", StringType, nil, func(ctx *Context, this string) *Value {
+		return StringValue(strings.TrimSpace(this))
+	})
+
+	stringFunction("startsWith", BooleanType, []Type{StringType}, func(ctx *Context, this string) *Value {
+		return BooleanValue(strings.HasPrefix(this, stringParameter(ctx, 1)))
+	})
+
+	stringFunction("endsWith", BooleanType, []Type{StringType}, func(ctx *Context, this string) *Value {
+		return BooleanValue(strings.HasSuffix(this, stringParameter(ctx, 1)))
+	})
+
+	stringFunction("contains", BooleanType, []Type{StringType}, func(ctx *Context, this string) *Value {
+		return BooleanValue(strings.Contains(this, stringParameter(ctx, 1)))
+	})
+
+	//indexOf gives the rune index of the first occurrence of the other string, or -1 if there isn't one
+	stringFunction("indexOf", IntType, []Type{StringType}, func(ctx *Context, this string) *Value {
+		offset := strings.Index(this, stringParameter(ctx, 1))
+		if offset == -1 {
+			return IntValue(-1)
+		}
+		return IntValue(int64(utf8.RuneCountInString(this[:offset])))
+	})
+
+	//replace replaces every occurrence
+	stringFunction("replace", StringType, []Type{StringType, StringType}, func(ctx *Context, this string) *Value {
+		return StringValue(strings.ReplaceAll(this, stringParameter(ctx, 1), stringParameter(ctx, 2)))
+	})
+
+	stringFunction("toUpper", StringType, nil, func(ctx *Context, this string) *Value {
+		return StringValue(strings.ToUpper(this))
+	})
+
+	stringFunction("toLower", StringType, nil, func(ctx *Context, this string) *Value {
+		return StringValue(strings.ToLower(this))
+	})
+
+	stringFunction("chars", NewCollectionTypeOf(CharType), nil, func(ctx *Context, this string) *Value {
+		chars := charsOf(this)
+		return NewValue(NewCollectionType(chars), chars)
+	})
+
+	//lines splits on both \n and \r\n, and ignores a trailing line break
+	stringFunction("lines", NewCollectionTypeOf(StringType), nil, func(ctx *Context, this string) *Value {
+		if this == "" {
+			return stringCollectionValue([]string{})
+		}
+		lines := strings.Split(strings.TrimSuffix(this, "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+		return stringCollectionValue(lines)
+	})
+
+	define(ctx, "stringBuilder", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{},
+			ReturnType: StringBuilderType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			return NonReturningValue(NewValue(StringBuilderType, &StringBuilder{}))
+		}),
+	})
+
+	defineImpure(ctx, "append", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: StringBuilderType,
+				},
+				{
+					Name:     "value",
+					Type:     AnyType,
+					Position: 1,
+				},
+			},
+			ReturnType: StringBuilderType,
+		},
+		//Returns the builder, so that calls can be chained
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			thisParam := ctx.FindParameter(0)
+			this := thisParam.Value.(*StringBuilder)
+			appending := ctx.Stringify(ctx.FindParameter(1))
+			this.builder.WriteString(appending)
+			this.length += utf8.RuneCountInString(appending)
+			return NonReturningValue(thisParam)
+		}),
+	})
+
+	define(ctx, "build", &Function{
+		Signature: Signature{
+			Parameters: []Parameter{
+				{
+					Name: "this",
+					Type: StringBuilderType,
+				},
+			},
+			ReturnType: StringType,
+		},
+		Body: NewAbstractCommand(func(ctx *Context) *ReturnedValue {
+			this := ctx.FindParameter(0).Value.(*StringBuilder)
+			return NonReturningValue(StringValue(this.builder.String()))
+		}),
+	})
+}
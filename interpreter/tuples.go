@@ -0,0 +1,75 @@
+package interpreter
+
+import "strings"
+
+//Tuples are fixed length and, unlike collections, can hold elements of different types
+type Tuple struct {
+	Elements []*Value
+}
+
+type TupleType struct {
+	ElementTypes []Type
+}
+
+func NewTupleType(tuple *Tuple) *TupleType {
+	elementTypes := make([]Type, len(tuple.Elements))
+	for i, element := range tuple.Elements {
+		elementTypes[i] = element.Type
+	}
+	return &TupleType{
+		ElementTypes: elementTypes,
+	}
+}
+
+func TupleValue(elements ...*Value) *Value {
+	tuple := &Tuple{Elements: elements}
+	return NewValue(NewTupleType(tuple), tuple)
+}
+
+func (t *TupleType) Name() string {
+	names := make([]string, len(t.ElementTypes))
+	for i, elementType := range t.ElementTypes {
+		names[i] = elementType.Name()
+	}
+	return "(" + strings.Join(names, ", ") + ")" //Eg (Int, String)
+}
+
+func (t *TupleType) Accepts(otherType Type, ctx *Context) bool {
+	otherTuple, ok := otherType.(*TupleType)
+	if !ok {
+		return false
+	}
+	if len(t.ElementTypes) != len(otherTuple.ElementTypes) {
+		return false
+	}
+	for i, elementType := range t.ElementTypes {
+		if !elementType.Accepts(otherTuple.ElementTypes[i], ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tuple) String() string {
+	elemStrings := make([]string, len(t.Elements))
+	for i, element := range t.Elements {
+		elemStrings[i] = element.String()
+	}
+	return "(" + strings.Join(elemStrings, ", ") + ")"
+}
+
+func (t *Tuple) Equals(ctx *Context, other *Value) bool {
+	otherAsTuple, otherIsTuple := other.Value.(*Tuple)
+	if !otherIsTuple {
+		return false
+	}
+	if len(t.Elements) != len(otherAsTuple.Elements) {
+		return false
+	}
+	for i, element := range t.Elements {
+		if !element.Equals(ctx, otherAsTuple.Elements[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/ElaraLang/elara/lexer"
 	"github.com/ElaraLang/elara/parser"
 	"reflect"
+	"sync"
 )
 
 type Type interface {
@@ -18,6 +19,14 @@ type StructType struct {
 	Properties        []Property     //This preserves ordering of properties
 	propertyPositions map[string]int //And this guarantees constant lookup still
 	constructor       *Value         //*Function of the constructor
+	namespace         string         //The namespace the struct was defined in, which restricted properties are limited to
+
+	TypeParameters []*TypeParameter //Non-empty for generic structs
+	TypeArguments  []Type           //The type arguments of a specialised generic struct, eg [Int] for Box<Int>
+	generic        *StructType      //The generic struct that this was specialised from, if any
+
+	specialisations    map[string]*StructType
+	specialisationLock sync.Mutex
 }
 
 func (t *StructType) Name() string {
@@ -47,6 +56,18 @@ func (t *StructType) GetProperty(identifier string) (Property, bool) {
 	return t.Properties[i], true
 }
 
+//checkAccess panics if the property is restricted and ctx is outside of the struct's namespace
+func (t *StructType) checkAccess(property Property, ctx *Context) {
+	if !t.canAccess(property, ctx) {
+		panic(restrictedAccessError(t.TypeName+"::"+property.Name, t.namespace, ctx.namespace))
+	}
+}
+
+//canAccess checks if a property can be used from the context's namespace, which it can't if it is restricted to another namespace
+func (t *StructType) canAccess(property Property, ctx *Context) bool {
+	return property.Modifiers&Restricted == 0 || t.namespace == ctx.namespace
+}
+
 type Property struct {
 	Name string
 	Type Type
@@ -156,6 +177,30 @@ func (t *DefinedType) Accepts(other Type, ctx *Context) bool {
 	return true
 }
 
+//CommonType finds the most specific type that accepts all of the given types.
+//In a proper type system we might try and find a union of all of them, but for now if they don't share a type the first one is used,
+//as collection literals always have, so [1, 2.5] is still an [Int]. Collections of Chars are treated as strings though, so they must only hold Chars
+func CommonType(types []Type, ctx *Context) Type {
+	if len(types) == 0 {
+		return AnyType
+	}
+	common := types[0]
+	for _, t := range types[1:] {
+		if common.Accepts(t, ctx) {
+			continue
+		}
+		if t.Accepts(common, ctx) {
+			common = t
+			continue
+		}
+		if types[0] == CharType {
+			return AnyType
+		}
+		return types[0]
+	}
+	return common
+}
+
 func FromASTType(astType parser.Type, ctx *Context) Type {
 	switch t := astType.(type) {
 	case parser.ElementaryTypeContract:
@@ -210,12 +255,45 @@ func FromASTType(astType parser.Type, ctx *Context) Type {
 			name:  t.Name,
 			parts: parts,
 		}
+	case parser.SetTypeContract:
+		return NewSetTypeOf(FromASTType(t.ElemType, ctx))
 	case parser.MapTypeContract:
 		keyType := FromASTType(t.KeyType, ctx)
 		valueType := FromASTType(t.ValueType, ctx)
 		return &MapType{
 			KeyType: keyType, ValueType: valueType,
 		}
+	case parser.TupleTypeContract:
+		elemTypes := make([]Type, len(t.ElemTypes))
+		for i, elemType := range t.ElemTypes {
+			elemTypes[i] = FromASTType(elemType, ctx)
+		}
+		return &TupleType{
+			ElementTypes: elemTypes,
+		}
+	case parser.GenericTypeContract:
+		typeArgs := make([]Type, len(t.TypeArgs))
+		for i, arg := range t.TypeArgs {
+			typeArgs[i] = FromASTType(arg, ctx)
+		}
+		switch t.Identifier {
+		case "Collection":
+			if len(typeArgs) != 1 {
+				panic("Collection expects 1 type argument")
+			}
+			return NewCollectionTypeOf(typeArgs[0])
+		case "Map":
+			if len(typeArgs) != 2 {
+				panic("Map expects 2 type arguments")
+			}
+			return &MapType{KeyType: typeArgs[0], ValueType: typeArgs[1]}
+		}
+		found := ctx.FindType(t.Identifier)
+		asStruct, isStruct := found.(*StructType)
+		if !isStruct {
+			panic("No such generic type " + t.Identifier)
+		}
+		return asStruct.Specialise(typeArgs, ctx)
 	}
 	panic("Cannot handle " + reflect.TypeOf(astType).Name())
 	return nil
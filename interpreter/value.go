@@ -3,6 +3,7 @@ package interpreter
 import (
 	"github.com/ElaraLang/elara/util"
 	"sync"
+	"sync/atomic"
 )
 
 type Value struct {
@@ -27,6 +28,18 @@ func (v *Value) Copy() *Value {
 var equalsNameHash = util.Hash("equals")
 
 func (v *Value) Equals(ctx *Context, b *Value) bool {
+	//Most comparisons are between values nobody has extended, so only look for an extension once one has been defined
+	if atomic.LoadInt32(&ctx.global.equalsExtensions) != 0 {
+		extension := ctx.FindExtension(v.Type, "equals")
+		if extension != nil {
+			//Equality can be overridden by extending a type
+			result, isBool := extension.Value.Value.Value.(*Function).Exec(ctx, []*Value{v, b}).Value.(bool)
+			if !isBool {
+				panic("equals function did not return bool")
+			}
+			return result
+		}
+	}
 	eqFunction := ctx.FindFunction(equalsNameHash, &Signature{
 		Parameters: []Parameter{
 			{Name: "this", Position: 0, Type: v.Type},
@@ -38,6 +51,10 @@ func (v *Value) Equals(ctx *Context, b *Value) bool {
 		res := eqFunction.Exec(ctx, []*Value{v, b}).Value.(bool)
 		return res
 	}
+	if goValue, isGo := v.Value.(*GoValue); isGo {
+		other, otherIsGo := b.Value.(*GoValue)
+		return otherIsGo && goValue.equals(other)
+	}
 	return v.Value == b.Value
 }
 
@@ -59,6 +76,43 @@ var returnedValues = sync.Pool{
 type ReturnedValue struct {
 	Value       *Value
 	IsReturning bool
+	Jump        JumpType //Set while a break or continue is unwinding to its loop
+	Label       string   //The label of the loop being jumped to, or empty for the innermost loop
+}
+
+type JumpType int
+
+const (
+	NoJump JumpType = iota
+	BreakJump
+	ContinueJump
+)
+
+func JumpValue(jump JumpType, label string) *ReturnedValue {
+	r := NewReturningValue(nil, false)
+	r.Jump = jump
+	r.Label = label
+	return r
+}
+
+//Interrupted returns true if the value is unwinding out of a block, because of a return, break or continue
+func (r *ReturnedValue) Interrupted() bool {
+	return r.IsReturning || r.Jump != NoJump
+}
+
+//checkNoJump panics if a break or continue has escaped every loop
+func (r *ReturnedValue) checkNoJump() {
+	if r.Jump == NoJump {
+		return
+	}
+	keyword := "break"
+	if r.Jump == ContinueJump {
+		keyword = "continue"
+	}
+	if r.Label != "" {
+		panic("No enclosing loop with label " + r.Label + " to " + keyword)
+	}
+	panic("Cannot " + keyword + " outside of a loop")
 }
 
 func NewReturningValue(value *Value, returning bool) *ReturnedValue {
@@ -82,6 +136,8 @@ func NilValue() *ReturnedValue {
 func (r *ReturnedValue) clean() {
 	r.Value = nil
 	r.IsReturning = false
+	r.Jump = NoJump
+	r.Label = ""
 	returnedValues.Put(r)
 }
 
@@ -89,6 +145,7 @@ func (r ReturnedValue) Unwrap() *Value {
 	if r.IsReturning {
 		panic("Value should return")
 	}
+	r.checkNoJump()
 	val := r.Value
 	r.clean()
 	return val
@@ -98,6 +155,7 @@ func (r ReturnedValue) UnwrapNotNil() *Value {
 	if r.IsReturning {
 		panic("Value should return")
 	}
+	r.checkNoJump()
 	if r.Value == nil {
 		panic("Value must not be nil")
 	}
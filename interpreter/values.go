@@ -24,10 +24,5 @@ func CharValue(value rune) *Value {
 }
 
 func StringValue(value string) *Value {
-	chars := make([]*Value, len(value))
-	for i, c := range value {
-		chars[i] = CharValue(c)
-	}
-	val := &Collection{Elements: chars, ElementType: CharType}
-	return NewValue(NewCollectionTypeOf(CharType), val)
+	return NewValue(StringType, value)
 }
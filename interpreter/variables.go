@@ -7,6 +7,45 @@ type Variable struct {
 	Mutable bool
 	Type    Type
 	Value   *Value
+	Lazy    *LazyValue //Non-nil for lazy bindings, in which case Value is unused
+
+	Restricted bool   //Restricted variables can only be accessed from inside their own namespace
+	Namespace  string //The namespace the variable was defined in
+	parallel   bool   //Set for variables defined by a parallel function, which are the only ones it can reassign
+}
+
+//AccessibleFrom returns if code in the given namespace is allowed to reference this variable
+func (v *Variable) AccessibleFrom(namespace string) bool {
+	return !v.Restricted || v.Namespace == namespace
+}
+
+//Get returns the value of the variable, evaluating it first if it is a lazy binding
+func (v *Variable) Get(ctx *Context) *Value {
+	if v.Lazy != nil {
+		return v.Lazy.Force(ctx)
+	}
+	return v.Value
+}
+
+//functionSignature returns the signature of the function that the variable holds, or nil if it doesn't hold one.
+//Lazy bindings that haven't been evaluated yet aren't forced: their declared type is used instead, if it is a function type
+func (v *Variable) functionSignature() *Signature {
+	value := v.Value
+	if v.Lazy != nil {
+		evaluated, isEvaluated := v.Lazy.evaluatedValue()
+		if !isEvaluated {
+			if functionType, isFunctionType := v.Lazy.declaredType.(*FunctionType); isFunctionType {
+				return &functionType.Signature
+			}
+			return nil
+		}
+		value = evaluated
+	}
+	function, isFunction := value.Value.(*Function)
+	if !isFunction {
+		return nil
+	}
+	return &function.Signature
 }
 
 func (v Variable) String() string {
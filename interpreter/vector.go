@@ -0,0 +1,202 @@
+package interpreter
+
+//vector is a persistent vector, implemented as a trie with 32 elements in each node.
+//Every "modification" returns a new vector that shares all of its unchanged nodes with the old one,
+//so appending and setting elements are O(log32 n), which is effectively constant.
+//The last (up to) 32 elements are kept in a separate tail, so appending usually doesn't touch the trie at all.
+type vector struct {
+	size  int
+	shift uint
+	root  *vectorNode
+	tail  []interface{}
+}
+
+//Internal nodes only have children, and leaf nodes only have values
+type vectorNode struct {
+	children []*vectorNode
+	values   []interface{}
+}
+
+const vectorBits = 5
+const vectorWidth = 1 << vectorBits
+const vectorMask = vectorWidth - 1
+
+var emptyVector = &vector{
+	size:  0,
+	shift: vectorBits,
+	root:  &vectorNode{},
+	tail:  []interface{}{},
+}
+
+//tailOffset is the index of the first element in the tail
+func (v *vector) tailOffset() int {
+	if v.size < vectorWidth {
+		return 0
+	}
+	return ((v.size - 1) >> vectorBits) << vectorBits
+}
+
+//leafFor finds the array of values holding the element at the index
+func (v *vector) leafFor(index int) []interface{} {
+	if index >= v.tailOffset() {
+		return v.tail
+	}
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(index>>level)&vectorMask]
+	}
+	return node.values
+}
+
+func (v *vector) get(index int) interface{} {
+	return v.leafFor(index)[index&vectorMask]
+}
+
+func (v *vector) set(index int, value interface{}) *vector {
+	if index >= v.tailOffset() {
+		tail := make([]interface{}, len(v.tail))
+		copy(tail, v.tail)
+		tail[index&vectorMask] = value
+		return &vector{
+			size:  v.size,
+			shift: v.shift,
+			root:  v.root,
+			tail:  tail,
+		}
+	}
+	return &vector{
+		size:  v.size,
+		shift: v.shift,
+		root:  setInNode(v.shift, v.root, index, value),
+		tail:  v.tail,
+	}
+}
+
+func setInNode(level uint, node *vectorNode, index int, value interface{}) *vectorNode {
+	if level == 0 {
+		values := make([]interface{}, len(node.values))
+		copy(values, node.values)
+		values[index&vectorMask] = value
+		return &vectorNode{values: values}
+	}
+	children := make([]*vectorNode, len(node.children))
+	copy(children, node.children)
+	subIndex := (index >> level) & vectorMask
+	children[subIndex] = setInNode(level-vectorBits, node.children[subIndex], index, value)
+	return &vectorNode{children: children}
+}
+
+func (v *vector) append(value interface{}) *vector {
+	if len(v.tail) < vectorWidth {
+		tail := make([]interface{}, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = value
+		return &vector{
+			size:  v.size + 1,
+			shift: v.shift,
+			root:  v.root,
+			tail:  tail,
+		}
+	}
+	return v.pushTail([]interface{}{value})
+}
+
+//appendAll appends every value, filling the tail a chunk at a time rather than copying it for every element
+func (v *vector) appendAll(values []interface{}) *vector {
+	result := v
+	for len(values) > 0 {
+		if len(result.tail) == vectorWidth {
+			chunk := vectorWidth
+			if len(values) < chunk {
+				chunk = len(values)
+			}
+			tail := make([]interface{}, chunk)
+			copy(tail, values)
+			result = result.pushTail(tail)
+			values = values[chunk:]
+			continue
+		}
+		space := vectorWidth - len(result.tail)
+		if len(values) < space {
+			space = len(values)
+		}
+		tail := make([]interface{}, len(result.tail)+space)
+		copy(tail, result.tail)
+		copy(tail[len(result.tail):], values[:space])
+		result = &vector{
+			size:  result.size + space,
+			shift: result.shift,
+			root:  result.root,
+			tail:  tail,
+		}
+		values = values[space:]
+	}
+	return result
+}
+
+//pushTail moves the full tail into the trie, and starts a new tail with the given values
+func (v *vector) pushTail(newTail []interface{}) *vector {
+	tailNode := &vectorNode{values: v.tail}
+	shift := v.shift
+	var root *vectorNode
+	if (v.size >> vectorBits) > (1 << v.shift) {
+		//The trie is full, so it needs another level
+		root = &vectorNode{children: []*vectorNode{v.root, newVectorPath(v.shift, tailNode)}}
+		shift += vectorBits
+	} else {
+		root = v.pushTailInto(v.shift, v.root, tailNode)
+	}
+	return &vector{
+		size:  v.size + len(newTail),
+		shift: shift,
+		root:  root,
+		tail:  newTail,
+	}
+}
+
+func (v *vector) pushTailInto(level uint, parent *vectorNode, tailNode *vectorNode) *vectorNode {
+	subIndex := ((v.size - 1) >> level) & vectorMask
+	children := make([]*vectorNode, len(parent.children), len(parent.children)+1)
+	copy(children, parent.children)
+	var inserting *vectorNode
+	if level == vectorBits {
+		inserting = tailNode
+	} else if subIndex < len(parent.children) {
+		inserting = v.pushTailInto(level-vectorBits, parent.children[subIndex], tailNode)
+	} else {
+		inserting = newVectorPath(level-vectorBits, tailNode)
+	}
+	if subIndex < len(children) {
+		children[subIndex] = inserting
+	} else {
+		children = append(children, inserting)
+	}
+	return &vectorNode{children: children}
+}
+
+func newVectorPath(level uint, node *vectorNode) *vectorNode {
+	if level == 0 {
+		return node
+	}
+	return &vectorNode{children: []*vectorNode{newVectorPath(level-vectorBits, node)}}
+}
+
+//forEach calls the consumer with every element in order, until it returns false
+func (v *vector) forEach(consumer func(index int, value interface{}) bool) {
+	index := 0
+	tailOffset := v.tailOffset()
+	for index < tailOffset {
+		for _, value := range v.leafFor(index) {
+			if !consumer(index, value) {
+				return
+			}
+			index++
+		}
+	}
+	for _, value := range v.tail {
+		if !consumer(index, value) {
+			return
+		}
+		index++
+	}
+}
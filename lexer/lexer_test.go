@@ -53,6 +53,26 @@ func TestStringAssignmentLexing(t *testing.T) {
 	}
 }
 
+func TestStringEscapeLexing(t *testing.T) {
+	tokens := Lex(`"say \"hi\"\n" "C:\dir" a`)
+	//Unknown escapes keep their backslash
+	expectedText := []string{"say \"hi\"\n", `C:\dir`, "a"}
+	//Columns count escapes as they are written, so they are the same as for a string without escapes of the same width
+	unescaped := Lex(`"say xxhixxxn" "C:\dir" a`)
+
+	if len(tokens) != len(expectedText) {
+		t.Fatalf("Expected %d tokens but got %v", len(expectedText), tokens)
+	}
+	for i, token := range tokens {
+		if string(token.Text) != expectedText[i] {
+			t.Errorf("Expected token %d to be %q but got %q", i, expectedText[i], string(token.Text))
+		}
+		if token.Position != unescaped[i].Position {
+			t.Errorf("Expected token %d to be at %v but got %v", i, unescaped[i].Position, token.Position)
+		}
+	}
+}
+
 func TestBooleanAssignmentLexing(t *testing.T) {
 	code := `let a = true`
 	tokens := Lex(code)
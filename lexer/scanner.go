@@ -80,6 +80,14 @@ func (s *TokenReader) Read() (tok TokenType, text []rune, line int, col int) {
 		return Colon, []rune{ch}, s.line, s.col
 	}
 
+	if ch == '#' && s.peek() == '{' {
+		s.Advance()
+		defer func() {
+			s.col += 2
+		}()
+		return HashBrace, []rune{'#', '{'}, s.line, s.col
+	}
+
 	if isAngleBracket(ch) {
 		s.unread()
 		bracket, t := s.readAngleBracket()
@@ -128,9 +136,10 @@ func (s *TokenReader) Read() (tok TokenType, text []rune, line int, col int) {
 	}
 
 	if ch == '"' {
-		str, t := s.readString()
+		start := s.cursor
+		str, t := s.readString(true)
 		defer func() {
-			s.col += len(t)
+			s.col += s.cursor - start - 1 //The width of the string in the source, which escapes make different to the width of t
 		}()
 		return str, t, s.line, s.col
 	}
@@ -143,6 +152,15 @@ func (s *TokenReader) Read() (tok TokenType, text []rune, line int, col int) {
 		return char, []rune{t}, s.line, s.col
 	}
 
+	if ch == 'r' && s.peek() == 'e' && s.cursor+1 < len(s.runes) && s.runes[s.cursor+1] == '"' {
+		s.cursor += 2 //Skip the e and opening quote
+		_, t := s.readString(false) //Regexes use backslashes themselves, so they are read raw
+		defer func() {
+			s.col += len(t)
+		}()
+		return Regex, t, s.line, s.col
+	}
+
 	if isValidIdentifier(ch) {
 		s.unread()
 		identifier, t := s.readIdentifier()
@@ -227,6 +245,9 @@ func (s *TokenReader) readIdentifier() (tok TokenType, text []rune) {
 				if str[1] == 's' {
 					return Is, str
 				}
+				if str[1] == 'n' {
+					return In, str
+				}
 			}
 			if length == 6 && str[1] == 'm' && str[2] == 'p' && str[3] == 'o' && str[4] == 'r' && str[5] == 't' {
 				return Import, str
@@ -250,6 +271,15 @@ func (s *TokenReader) readIdentifier() (tok TokenType, text []rune) {
 	if runeSliceEq(str, []rune("while")) {
 		return While, str
 	}
+	if runeSliceEq(str, []rune("for")) {
+		return For, str
+	}
+	if runeSliceEq(str, []rune("break")) {
+		return Break, str
+	}
+	if runeSliceEq(str, []rune("continue")) {
+		return Continue, str
+	}
 	if runeSliceEq(str, []rune("struct")) {
 		return Struct, str
 	}
@@ -300,6 +330,10 @@ func (s *TokenReader) readSymbol() (tok TokenType, text []rune) {
 
 	switch ch {
 	case '.':
+		if s.peek() == '.' {
+			s.Advance()
+			return Range, []rune{ch, ch}
+		}
 		return Dot, []rune{ch}
 	case '=':
 		peeked := s.peek()
@@ -430,9 +464,11 @@ func (s *TokenReader) readOperator() (tok TokenType, text []rune) {
 }
 
 //This function is called with the assumption that the beginning " has ALREADY been Advance.
-func (s *TokenReader) readString() (tok TokenType, text []rune) {
+//If escapes is true, escape sequences such as \" and \n are replaced with the characters they represent
+func (s *TokenReader) readString(escapes bool) (tok TokenType, text []rune) {
 	start := s.cursor
 	end := start
+	text = make([]rune, 0)
 
 	for {
 		r := s.runes[end]
@@ -443,12 +479,39 @@ func (s *TokenReader) readString() (tok TokenType, text []rune) {
 		if r == '"' {
 			break
 		}
+		if escapes && r == '\\' && end < len(s.runes) {
+			if escaped, isEscape := escapedRune(s.runes[end]); isEscape {
+				r = escaped
+				end++
+			}
+		}
+		text = append(text, r)
 		if end >= len(s.runes) {
 			break
 		}
 	}
 	s.cursor = end
-	return String, s.runes[start : end-1]
+	return String, text
+}
+
+//escapedRune gives the character that a backslash followed by r represents.
+//Anything else isn't an escape, and the backslash is kept as it is, so that strings such as "C:\dir" don't need their backslashes doubled
+func escapedRune(r rune) (rune, bool) {
+	switch r {
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case '"':
+		return '"', true
+	case '\\':
+		return '\\', true
+	case 'b':
+		return '\b', true
+	}
+	return r, false
 }
 
 //This function is called with the assumption that the beginning ' has ALREADY been Advance.
@@ -497,6 +560,9 @@ func (s *TokenReader) readNumber() (tok TokenType, text []rune) {
 			break
 		}
 		if r == '.' {
+			if end+1 < len(s.runes) && s.runes[end+1] == '.' {
+				break //The start of a range, eg 0..10
+			}
 			if numType == Float {
 				break
 			}
@@ -17,12 +17,17 @@ const (
 	RAngle //>
 	LSquare
 	RSquare
+	HashBrace //#{, the start of a set literal
 
 	//Keywords
 	Let
 	Extend
 	Return
 	While
+	For
+	In
+	Break
+	Continue
 	Mut
 	Lazy
 	Restricted
@@ -58,11 +63,13 @@ const (
 	Equal
 	Arrow
 	Dot
+	Range // ..
 
 	//Literals
 	BooleanTrue
 	BooleanFalse
 	String
+	Regex //re"pattern"
 	Char
 	Int
 	Float
@@ -90,12 +97,17 @@ var tokenNames = map[TokenType]string{
 	LAngle:       "LAngle",
 	RAngle:       "RAngle",
 	LSquare:      "LSquare",
+	HashBrace:    "HashBrace",
 	RSquare:      "RSquare",
 	Type:         "Type",
 	Let:          "Let",
 	Extend:       "Extend",
 	Return:       "Return",
 	While:        "While",
+	For:          "For",
+	In:           "In",
+	Break:        "Break",
+	Continue:     "Continue",
 	Mut:          "Mut",
 	Lazy:         "Lazy",
 	Restricted:   "Restricted",
@@ -123,9 +135,11 @@ var tokenNames = map[TokenType]string{
 	Equal:        "Equal",
 	Arrow:        "Arrow",
 	Dot:          "Dot",
+	Range:        "Range",
 	BooleanTrue:  "True",
 	BooleanFalse: "False",
 	String:       "String",
+	Regex:        "Regex",
 	Char:         "Char",
 	Int:          "Int",
 	Float:        "Float",
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/ElaraLang/elara/lexer"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -34,8 +35,9 @@ type AssignmentExpr struct {
 }
 
 type InvocationExpr struct {
-	Invoker Expr
-	Args    []Expr
+	Invoker  Expr
+	TypeArgs []Type //Explicit type arguments, may be empty
+	Args     []Expr
 }
 
 type ContextExpr struct {
@@ -76,6 +78,21 @@ type CollectionExpr struct {
 	Elements []Expr
 }
 
+//SetExpr is a set literal, eg #{1, 2, 3}
+type SetExpr struct {
+	Elements []Expr
+}
+
+type TupleExpr struct {
+	Elements []Expr
+}
+
+//RangeExpr is a range of integers, eg 0..10. The end is exclusive
+type RangeExpr struct {
+	Start Expr
+	End   Expr
+}
+
 type MapExpr struct {
 	Entries []MapEntry
 }
@@ -88,6 +105,11 @@ type MapEntry struct {
 type StringLiteralExpr struct {
 	Value string
 }
+//RegexLiteralExpr is a regular expression literal, eg re"[0-9]+". The pattern is checked when it is parsed
+type RegexLiteralExpr struct {
+	Pattern string
+}
+
 type CharLiteralExpr struct {
 	Value rune
 }
@@ -107,9 +129,13 @@ type BooleanLiteralExpr struct {
 func (FuncDefExpr) exprNode()        {}
 func (AccessExpr) exprNode()         {}
 func (CollectionExpr) exprNode()     {}
+func (SetExpr) exprNode()            {}
+func (TupleExpr) exprNode()          {}
+func (RangeExpr) exprNode()          {}
 func (MapExpr) exprNode()            {}
 func (StringLiteralExpr) exprNode()  {}
 func (CharLiteralExpr) exprNode()    {}
+func (RegexLiteralExpr) exprNode()   {}
 func (IntegerLiteralExpr) exprNode() {}
 func (FloatLiteralExpr) exprNode()   {}
 func (BooleanLiteralExpr) exprNode() {}
@@ -232,11 +258,11 @@ func (p *Parser) referenceEquality() (expr Expr) {
 }
 
 func (p *Parser) comparison() (expr Expr) {
-	expr = p.addition()
+	expr = p.rangeExpression()
 
 	for p.match(lexer.GreaterEqual, lexer.RAngle, lexer.LesserEqual, lexer.LAngle) {
 		op := p.previous()
-		rhs := p.addition()
+		rhs := p.rangeExpression()
 
 		expr = BinaryExpr{
 			Lhs: expr,
@@ -247,6 +273,17 @@ func (p *Parser) comparison() (expr Expr) {
 	return
 }
 
+func (p *Parser) rangeExpression() (expr Expr) {
+	expr = p.addition()
+	if p.match(lexer.Range) {
+		expr = RangeExpr{
+			Start: expr,
+			End:   p.addition(),
+		}
+	}
+	return
+}
+
 func (p *Parser) addition() (expr Expr) {
 	expr = p.multiplication()
 
@@ -294,15 +331,23 @@ func (p *Parser) unary() (expr Expr) {
 func (p *Parser) invoke() (expr Expr) {
 	expr = p.funDef()
 
-	for p.match(lexer.LParen, lexer.Dot, lexer.LSquare) {
+	for {
+		var typeArgs []Type
+		if p.check(lexer.LAngle) && p.isInvocationTypeArguments() {
+			typeArgs = p.typeArguments()
+		}
+		if !p.match(lexer.LParen, lexer.Dot, lexer.LSquare) {
+			break
+		}
 		switch p.previous().TokenType {
 		case lexer.LParen:
 			separator := lexer.Comma
 			args := p.invocationParameters(&separator)
 
 			expr = InvocationExpr{
-				Invoker: expr,
-				Args:    args,
+				Invoker:  expr,
+				TypeArgs: typeArgs,
+				Args:     args,
 			}
 		case lexer.Dot:
 			id := p.consumeValidIdentifier("Expected identifier inside context getter/setter")
@@ -326,11 +371,14 @@ func (p *Parser) funDef() Expr {
 	tok := p.peek()
 	switch tok.TokenType {
 	case lexer.LParen:
+		if !p.isFuncDef() {
+			return p.collection() //A grouped expression or a tuple
+		}
 		args := p.functionArguments()
 		var typ Type
 		p.consume(lexer.Arrow, "Expected arrow at function definition")
 
-		if p.check(lexer.Identifier) && p.isBlockPresent() {
+		if (p.check(lexer.Identifier) || p.check(lexer.LSquare) || p.check(lexer.LParen)) && p.isBlockPresent() {
 			typ = p.typeContract()
 		}
 		return FuncDefExpr{
@@ -434,6 +482,22 @@ func (p *Parser) collection() (expr Expr) {
 			Elements: col,
 		}
 	}
+	if p.match(lexer.HashBrace) {
+		elements := make([]Expr, 0)
+		p.cleanNewLines()
+		for !p.check(lexer.RBrace) {
+			elements = append(elements, p.expression())
+			p.cleanNewLines()
+			if !p.match(lexer.Comma) {
+				break
+			}
+			p.cleanNewLines()
+		}
+		p.consume(lexer.RBrace, "Expected '}' at end of set literal")
+		return SetExpr{
+			Elements: elements,
+		}
+	}
 	expr = p.primary()
 	return
 }
@@ -449,6 +513,17 @@ func (p *Parser) primary() (expr Expr) {
 
 		expr = StringLiteralExpr{Value: text}
 		break
+	case lexer.Regex:
+		patternTok := p.consume(lexer.Regex, "Expected regex")
+		pattern := string(patternTok.Text)
+		_, compileErr := regexp.Compile(pattern)
+		if compileErr != nil {
+			panic(ParseError{
+				token:   patternTok,
+				message: "Invalid regular expression: " + compileErr.Error(),
+			})
+		}
+		expr = RegexLiteralExpr{Pattern: pattern}
 	case lexer.Char:
 		charTok := p.consume(lexer.Char, "Expected char")
 		char := charTok.Text[0]
@@ -482,8 +557,19 @@ func (p *Parser) primary() (expr Expr) {
 		return p.ifElseExpression()
 	case lexer.LParen:
 		p.advance()
-		expr = GroupExpr{Group: p.expression()}
-		p.consume(lexer.RParen, "Expected ')' after grouped expression")
+		first := p.expression()
+		if !p.check(lexer.Comma) {
+			expr = GroupExpr{Group: first}
+			p.consume(lexer.RParen, "Expected ')' after grouped expression")
+			break
+		}
+		elements := []Expr{first}
+		for p.match(lexer.Comma) {
+			p.cleanNewLines()
+			elements = append(elements, p.expression())
+		}
+		p.consume(lexer.RParen, "Expected ')' at end of tuple literal")
+		expr = TupleExpr{Elements: elements}
 	}
 
 	if err != nil {
@@ -8,6 +8,7 @@ type FunctionArgument struct {
 	Lazy    bool
 	Type    Type
 	Name    string
+	Pattern Pattern //Only present for destructured arguments, in which case Name is empty
 	Default Expr
 }
 
@@ -45,6 +46,17 @@ func (p *Parser) functionArguments() (args []FunctionArgument) {
 
 func (p *Parser) functionArgument() FunctionArgument {
 	lazy := p.parseProperties(lexer.Lazy)[0]
+	if p.check(lexer.LParen) && !p.isFuncDef() {
+		if lazy {
+			panic(ParseError{
+				token:   p.previous(),
+				message: "Lazy parameters cannot be destructured",
+			})
+		}
+		return FunctionArgument{
+			Pattern: p.tuplePattern(),
+		}
+	}
 	checkIndex := p.current + 1
 	var typ Type
 	if len(p.tokens) > checkIndex && p.tokens[checkIndex].TokenType != lexer.Equal {
@@ -65,8 +77,11 @@ func (p *Parser) functionArgument() FunctionArgument {
 
 func (p *Parser) isFuncDef() (result bool) {
 	closing := p.findParenClosingPoint(p.current)
+	if closing+1 >= len(p.tokens) {
+		return false
+	}
 	return p.tokens[closing+1].TokenType == lexer.Arrow ||
-		(p.tokens[closing+1].TokenType == lexer.Identifier && p.tokens[closing+2].TokenType == lexer.Arrow)
+		(p.tokens[closing+1].TokenType == lexer.Identifier && closing+2 < len(p.tokens) && p.tokens[closing+2].TokenType == lexer.Arrow)
 }
 
 func (p *Parser) findParenClosingPoint(start int) (index int) {
@@ -79,7 +94,7 @@ func (p *Parser) findParenClosingPoint(start int) (index int) {
 			cur = p.findParenClosingPoint(cur)
 		}
 		cur++
-		if cur > len(p.tokens) {
+		if cur >= len(p.tokens) {
 			panic(ParseError{
 				token:   p.previous(),
 				message: "Unexpected end before closing parenthesis",
@@ -89,17 +104,19 @@ func (p *Parser) findParenClosingPoint(start int) (index int) {
 	return cur
 }
 
+//isBlockPresent checks for a function body directly after a return type, eg the { in (Int a) => [Int] {
+//Only tokens that can be part of a type may come before it, so that a single expression function isn't mistaken for a return type.
 func (p *Parser) isBlockPresent() bool {
-	curIdx := p.current
-
-	for curIdx < len(p.tokens) {
+	for curIdx := p.current; curIdx < len(p.tokens); curIdx++ {
 		switch p.tokens[curIdx].TokenType {
 		case lexer.LBrace:
-			return true
-		case lexer.NEWLINE:
+			return curIdx != p.current
+		case lexer.Identifier, lexer.LAngle, lexer.RAngle, lexer.LSquare, lexer.RSquare, lexer.LParen, lexer.RParen,
+			lexer.Comma, lexer.Colon, lexer.TypeOr, lexer.TypeAnd:
+			continue
+		default:
 			return false
 		}
-		curIdx++
 	}
 	return false
 }
@@ -23,10 +23,52 @@ func (p *Parser) generic() (contracts []GenericContract) {
 	return
 }
 
+//typeArguments parses explicit type arguments, such as the <Int> in empty<Int>()
+func (p *Parser) typeArguments() (args []Type) {
+	p.consume(lexer.LAngle, "Expected type arguments to start with `<`")
+	args = make([]Type, 0)
+	for {
+		args = append(args, p.typeContract())
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	p.consume(lexer.RAngle, "Expected type arguments to end with `>`")
+	return
+}
+
+//maxTypeArgumentTokens bounds how far isInvocationTypeArguments looks for the end of the type arguments
+const maxTypeArgumentTokens = 64
+
+//isInvocationTypeArguments looks ahead for type arguments followed by an invocation, without parsing or consuming anything.
+//As `<` could also be the start of a comparison, it only matches if every token up to the closing `>` could be part of a type, and a `(` follows it.
+func (p *Parser) isInvocationTypeArguments() bool {
+	depth := 0
+	for i := p.current; i < len(p.tokens) && i < p.current+maxTypeArgumentTokens; i++ {
+		switch p.tokens[i].TokenType {
+		case lexer.LAngle:
+			depth++
+		case lexer.RAngle:
+			depth--
+			if depth == 0 {
+				return i+1 < len(p.tokens) && p.tokens[i+1].TokenType == lexer.LParen
+			}
+		case lexer.Identifier, lexer.Comma, lexer.LSquare, lexer.RSquare, lexer.LParen, lexer.RParen,
+			lexer.LBrace, lexer.RBrace, lexer.Colon, lexer.Arrow, lexer.TypeOr, lexer.TypeAnd:
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+//Contract may be nil if the generic type is unbounded (eg <T>)
 func (p *Parser) genericContract() (typContract GenericContract) {
 	typID := p.consume(lexer.Identifier, "Expected identifier for generic type")
-	p.consume(lexer.Colon, "Expected colon after generic type id")
-	contract := p.typeContractDefinable()
+	var contract Type
+	if p.match(lexer.Colon) {
+		contract = p.typeContractDefinable()
+	}
 	typContract = GenericContract{
 		Identifier: string(typID.Text),
 		Contract:   contract,
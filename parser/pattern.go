@@ -0,0 +1,58 @@
+package parser
+
+import "github.com/ElaraLang/elara/lexer"
+
+//Patterns are used on the left hand side of a destructuring binding, eg let (x, y) = point
+type Pattern interface {
+	patternNode()
+}
+
+//A single name in a pattern. Type may be nil, and an Identifier of _ discards the value
+type IdentifierPattern struct {
+	Identifier string
+	Type       Type
+}
+
+type TuplePattern struct {
+	Elements []Pattern
+}
+
+func (IdentifierPattern) patternNode() {}
+func (TuplePattern) patternNode()      {}
+
+func (p *Parser) tuplePattern() TuplePattern {
+	p.consume(lexer.LParen, "Expected '(' at start of destructuring pattern")
+	elements := make([]Pattern, 0)
+	for {
+		elements = append(elements, p.patternElement())
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	p.consume(lexer.RParen, "Expected ')' at end of destructuring pattern")
+	if len(elements) < 2 {
+		panic(ParseError{
+			token:   p.previous(),
+			message: "Destructuring patterns must have at least 2 elements",
+		})
+	}
+	return TuplePattern{Elements: elements}
+}
+
+func (p *Parser) patternElement() Pattern {
+	if p.check(lexer.LParen) {
+		return p.tuplePattern()
+	}
+	if p.check(lexer.Identifier) && p.current+1 < len(p.tokens) {
+		next := p.tokens[p.current+1].TokenType
+		if next == lexer.Comma || next == lexer.RParen {
+			return IdentifierPattern{Identifier: string(p.advance().Text)}
+		}
+	}
+	typ := p.typeContract()
+	id := p.consume(lexer.Identifier, "Expected identifier in destructuring pattern")
+	return IdentifierPattern{
+		Identifier: string(id.Text),
+		Type:       typ,
+	}
+}
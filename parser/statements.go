@@ -19,6 +19,7 @@ type VarDefStmt struct {
 	Lazy       bool
 	Restricted bool
 	Identifier string
+	Pattern    Pattern //Only present for destructuring bindings, in which case Identifier is empty
 	Type       Type
 	Value      Expr
 }
@@ -35,10 +36,27 @@ type IfElseStmt struct {
 }
 
 type WhileStmt struct {
+	Label     string //Empty if the loop is not labelled
 	Condition Expr
 	Body      Stmt
 }
 
+//ForStmt iterates over a collection, map or range, eg for x in [1, 2, 3]
+type ForStmt struct {
+	Label      string
+	Pattern    Pattern //Either a single identifier or a destructuring pattern, eg for (k, v) in map
+	Collection Expr
+	Body       Stmt
+}
+
+type BreakStmt struct {
+	Label string //Empty to break out of the innermost loop
+}
+
+type ContinueStmt struct {
+	Label string
+}
+
 type ExtendStmt struct {
 	Identifier string
 	Body       BlockStmt
@@ -63,6 +81,9 @@ func (VarDefStmt) stmtNode()     {}
 func (StructDefStmt) stmtNode()  {}
 func (IfElseStmt) stmtNode()     {}
 func (WhileStmt) stmtNode()      {}
+func (ForStmt) stmtNode()        {}
+func (BreakStmt) stmtNode()      {}
+func (ContinueStmt) stmtNode()   {}
 func (ExtendStmt) stmtNode()     {}
 func (GenerifiedStmt) stmtNode() {}
 func (TypeStmt) stmtNode()       {}
@@ -78,7 +99,20 @@ func (p *Parser) declaration() (stmt Stmt) {
 func (p *Parser) statement() Stmt {
 	switch p.peek().TokenType {
 	case lexer.While:
-		return p.whileStatement()
+		return p.whileStatement("")
+	case lexer.For:
+		return p.forStatement("")
+	case lexer.Break:
+		p.advance()
+		return BreakStmt{Label: p.jumpLabel()}
+	case lexer.Continue:
+		p.advance()
+		return ContinueStmt{Label: p.jumpLabel()}
+	case lexer.Identifier:
+		if p.isLabelledLoop() {
+			return p.labelledLoopStatement()
+		}
+		return p.exprStatement()
 	case lexer.If:
 		return p.ifStatement()
 	case lexer.LBrace:
@@ -106,6 +140,10 @@ func (p *Parser) varDefStatement() Stmt {
 	lazy := properties[1]
 	restricted := properties[2]
 
+	if p.check(lexer.LParen) {
+		return p.destructuringDefStatement(mut, lazy, restricted)
+	}
+
 	id := p.consume(lexer.Identifier, "Expected identifier for variable declaration")
 	var typ Type
 	if p.match(lexer.Colon) {
@@ -133,16 +171,87 @@ func (p *Parser) varDefStatement() Stmt {
 	}
 }
 
-func (p *Parser) whileStatement() Stmt {
+func (p *Parser) destructuringDefStatement(mut bool, lazy bool, restricted bool) Stmt {
+	if lazy {
+		panic(ParseError{
+			token:   p.previous(),
+			message: "Lazy bindings cannot be destructured",
+		})
+	}
+	pattern := p.tuplePattern()
+	var typ Type
+	if p.match(lexer.Colon) {
+		typ = p.typeContract()
+	}
+	p.consume(lexer.Equal, "Expected Equal on variable declaration")
+	expr := p.expression()
+
+	return VarDefStmt{
+		Mutable:    mut,
+		Restricted: restricted,
+		Pattern:    pattern,
+		Type:       typ,
+		Value:      expr,
+	}
+}
+
+func (p *Parser) whileStatement(label string) Stmt {
 	p.consume(lexer.While, "Expected while at beginning of while loop")
 	expr := p.expression()
 	body := p.blockStatement()
 	return WhileStmt{
+		Label:     label,
 		Condition: expr,
 		Body:      body,
 	}
 }
 
+func (p *Parser) forStatement(label string) Stmt {
+	p.consume(lexer.For, "Expected for at beginning of for loop")
+	var pattern Pattern
+	if p.check(lexer.LParen) {
+		pattern = p.tuplePattern()
+	} else {
+		id := p.consume(lexer.Identifier, "Expected identifier after for")
+		pattern = IdentifierPattern{Identifier: string(id.Text)}
+	}
+	p.consume(lexer.In, "Expected in after for loop variable")
+	collection := p.expression()
+	body := p.blockStatement()
+	return ForStmt{
+		Label:      label,
+		Pattern:    pattern,
+		Collection: collection,
+		Body:       body,
+	}
+}
+
+//isLabelledLoop checks for a loop with a label in front of it, eg outer: for x in xs
+func (p *Parser) isLabelledLoop() bool {
+	if p.current+2 >= len(p.tokens) || p.tokens[p.current+1].TokenType != lexer.Colon {
+		return false
+	}
+	loop := p.tokens[p.current+2].TokenType
+	return loop == lexer.While || loop == lexer.For
+}
+
+func (p *Parser) labelledLoopStatement() Stmt {
+	label := string(p.consume(lexer.Identifier, "Expected loop label").Text)
+	p.consume(lexer.Colon, "Expected : after loop label")
+	if p.check(lexer.For) {
+		return p.forStatement(label)
+	}
+	return p.whileStatement(label)
+}
+
+//jumpLabel parses the optional label after a break or continue
+func (p *Parser) jumpLabel() string {
+	if p.check(lexer.Identifier) {
+		return string(p.advance().Text)
+	}
+	return ""
+}
+
 func (p *Parser) ifStatement() (stmt Stmt) {
 	p.consume(lexer.If, "Expected if at beginning of if statement")
 	condition := p.logicalOr()
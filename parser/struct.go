@@ -4,6 +4,7 @@ import "github.com/ElaraLang/elara/lexer"
 
 type StructField struct {
 	Mutable    bool
+	Restricted bool
 	Identifier string
 	FieldType  *Type
 	Default    Expr
@@ -29,7 +30,9 @@ func (p *Parser) structFields() (fields []StructField) {
 }
 
 func (p *Parser) structField() (field *StructField) {
-	mutable := p.match(lexer.Mut)
+	properties := p.parseProperties(lexer.Mut, lexer.Restricted)
+	mutable := properties[0]
+	restricted := properties[1]
 	t1 := p.advance()
 	t2 := p.advance()
 	var typ Type
@@ -62,6 +65,7 @@ func (p *Parser) structField() (field *StructField) {
 	}
 	return &StructField{
 		Mutable:    mutable,
+		Restricted: restricted,
 		Identifier: identifier,
 		FieldType:  &typ,
 		Default:    def,
@@ -28,11 +28,26 @@ type CollectionTypeContract struct {
 	ElemType Type
 }
 
+//A set type, eg Set<Int>
+type SetTypeContract struct {
+	ElemType Type
+}
+
 type MapTypeContract struct {
 	KeyType   Type
 	ValueType Type
 }
 
+type TupleTypeContract struct {
+	ElemTypes []Type
+}
+
+//A generic type applied to type arguments, eg Box<Int>
+type GenericTypeContract struct {
+	Identifier string
+	TypeArgs   []Type
+}
+
 func (p *Parser) typeContract() (contract Type) {
 	return p.contractualOr(false)
 }
@@ -74,12 +89,28 @@ func (p *Parser) contractualAnd(allowDef bool) (contract Type) {
 func (p *Parser) primaryContract(allowDef bool) (contract Type) {
 	if p.peek().TokenType == lexer.LSquare {
 		p.advance()
-		collType := p.consume(lexer.Identifier, "Expected identifier after [ for collection type")
+		elemType := p.typeContract()
 		p.consume(lexer.RSquare, "Expected ] after [ for collection type")
-		return CollectionTypeContract{ElemType: ElementaryTypeContract{Identifier: string(collType.Text)}}
+		return CollectionTypeContract{ElemType: elemType}
 	}
 	if p.peek().TokenType == lexer.Identifier {
 		name := string(p.advance().Text)
+		if name == "Set" && p.check(lexer.LAngle) {
+			typeArgs := p.typeArguments()
+			if len(typeArgs) != 1 {
+				panic(ParseError{
+					token:   p.previous(),
+					message: "Set expects 1 type argument",
+				})
+			}
+			return SetTypeContract{ElemType: typeArgs[0]}
+		}
+		if p.check(lexer.LAngle) {
+			return GenericTypeContract{
+				Identifier: name,
+				TypeArgs:   p.typeArguments(),
+			}
+		}
 		return ElementaryTypeContract{Identifier: name}
 	} else if p.check(lexer.LParen) {
 		isFunc := p.isFuncDef()
@@ -103,10 +134,16 @@ func (p *Parser) primaryContract(allowDef bool) (contract Type) {
 				ReturnType: ret,
 			}
 		} else {
-			contract = p.contractualOr(allowDef)
-
-			p.consume(lexer.LParen, "contract group not closed. Expected '}'")
-			return
+			p.consume(lexer.LParen, "Expected '(' at start of contract group")
+			types := []Type{p.contractualOr(allowDef)}
+			for p.match(lexer.Comma) {
+				types = append(types, p.contractualOr(allowDef))
+			}
+			p.consume(lexer.RParen, "contract group not closed. Expected ')'")
+			if len(types) == 1 {
+				return types[0]
+			}
+			return TupleTypeContract{ElemTypes: types}
 		}
 	}
 	if p.peek().TokenType == lexer.LBrace {
@@ -163,3 +200,6 @@ func (t InvocableTypeContract) typeOf()  {}
 func (t DefinedTypeContract) typeOf()    {}
 func (t CollectionTypeContract) typeOf() {}
 func (t MapTypeContract) typeOf()        {}
+func (t SetTypeContract) typeOf()        {}
+func (t GenericTypeContract) typeOf()    {}
+func (t TupleTypeContract) typeOf()      {}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestCollectionTransformations(t *testing.T) {
+	code := `let xs = [3, 1, 2, 3]
+xs.map((Int x) => x * 2)
+xs.filter((Int x) => x != 3)
+xs.flatMap((Int x) => [x, x]).size
+xs.sortBy((Int a, Int b) => a - b)
+xs.distinct()
+xs.reversed()
+xs.take(2)
+xs.drop(3)
+xs.joinToString(", ")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expected := []string{"[6, 2, 4, 6]", "[1, 2]", "8", "[1, 2, 3, 3]", "[3, 1, 2]", "[3, 2, 1, 3]", "[3, 1]", "[3]", "3, 1, 2, 3"}
+	actual := make([]string, 0)
+	for _, result := range results[1:] {
+		actual = append(actual, result.String())
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Incorrect collection output, got %v but expected %v", actual, expected)
+	}
+}
+
+func TestCollectionQueries(t *testing.T) {
+	code := `let xs = [3, 1, 2, 3]
+xs.fold(0, (Int acc, Int x) => acc + x)
+xs.reduce((Int a, Int b) => a * b)
+xs.any((Int x) => x == 2)
+xs.all((Int x) => x == 2)
+xs.find((Int x) => x == 1)
+xs.groupBy((Int x) => x == 3)[true].size`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		interpreter.IntValue(9),
+		interpreter.IntValue(18),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		interpreter.IntValue(1),
+		interpreter.IntValue(2),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect collection output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestCollectionElementTypes(t *testing.T) {
+	code := `let xs = [1, 2]
+xs.map((Int x) => x * 2) is [Int]
+xs.map((Int x) => x.toString()) is [String]
+xs.filter((Int x) => x == 1) is [Int]
+xs.zip(["a", "b"]) is [(Int, String)]
+[1, 2.5] is [Int]
+xs.groupBy((Int x) => x == 1) is {Boolean : [Int]}`
+	results, _, _, _ := base.Execute(nil, code, false)
+	for i, result := range results[1:] {
+		if !reflect.DeepEqual(result, interpreter.BooleanValue(true)) {
+			t.Errorf("Incorrect element type for result %d, got %v", i, formatValues(results))
+		}
+	}
+}
+
+func TestCollectionPersistence(t *testing.T) {
+	code := `let xs = [1, 2, 3]
+let ys = xs + [4]
+let mut zs = [0]
+for i in 1..2000 {
+    zs = zs + [i]
+}
+xs
+ys
+zs.size
+zs[1999]`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(1), interpreter.IntValue(2), interpreter.IntValue(3)}),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(1), interpreter.IntValue(2), interpreter.IntValue(3), interpreter.IntValue(4)}),
+		interpreter.IntValue(2000),
+		interpreter.IntValue(1999),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:], expectedResults) {
+		t.Errorf("Incorrect collection output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestListBuilder(t *testing.T) {
+	code := `let builder = listBuilder()
+for i in 0..5 {
+    builder.add(i * i)
+}
+let built = builder.build()
+builder.set(0, 100)
+built
+builder.build()
+builder.size`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(0), interpreter.IntValue(1), interpreter.IntValue(4), interpreter.IntValue(9), interpreter.IntValue(16)}),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(100), interpreter.IntValue(1), interpreter.IntValue(4), interpreter.IntValue(9), interpreter.IntValue(16)}),
+		interpreter.IntValue(5),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-3:], expectedResults) {
+		t.Errorf("Incorrect list builder output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
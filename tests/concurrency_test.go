@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTasksAndChannels(t *testing.T) {
+	code := `let squares = channel<Int>(0)
+let producer = spawn(() => {
+    for i in 1..4 {
+        squares.send(i * i)
+    }
+    squares.close()
+})
+let mut total = 0
+for i in 0..3 {
+    total = total + squares.receive()
+}
+total
+squares.receive() is Unit
+spawn(() => 5 + 5).await()
+spawn(() => [1][3]).await() is Error`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(14),
+		interpreter.BooleanValue(true),
+		interpreter.IntValue(10),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:], expectedResults) {
+		t.Errorf("Incorrect task output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestSharedVariablesAreProtected(t *testing.T) {
+	code := `let mut counter = 0
+let increment = () => {
+    for i in 0..100 {
+        counter = counter + 1
+    }
+}
+let tasks = [spawn(increment), spawn(increment), spawn(increment), spawn(increment)]
+tasks.map((Task task) => task.await())
+counter`
+	results, _, _, _ := base.Execute(nil, code, false)
+	if expected := interpreter.IntValue(400); !reflect.DeepEqual(results[len(results)-1], expected) {
+		t.Errorf("Expected every increment to be counted, but got %s", results[len(results)-1])
+	}
+}
+
+func TestSelect(t *testing.T) {
+	code := `let empty = channel<String>(0)
+let ready = channel<String>(1)
+ready.send("hello")
+select([onReceive(empty, (Any value) => "empty"), onReceive(ready, (Any value) => "ready " + value)])
+select([onReceive(empty, (Any value) => "empty"), onTimeout(10, () => "timed out")])
+select([onSend(ready, "again", () => "sent"), onTimeout(10, () => "timed out")])
+ready.receive()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("ready hello"),
+		interpreter.StringValue("timed out"),
+		interpreter.StringValue("sent"),
+		interpreter.StringValue("again"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:], expectedResults) {
+		t.Errorf("Incorrect select output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestTaskCancellation(t *testing.T) {
+	runtime := elara.New()
+	result, err := runtime.Eval(`let forever = spawn(() => {
+    while true {
+    }
+})
+forever.cancel()
+forever.await() is Error`)
+	if err != nil || result != true {
+		t.Errorf("Expected a cancelled task to fail, but got %v, %v", result, err)
+	}
+
+	//Cancelling the script cancels the tasks that it started, including ones waiting on a channel
+	goContext, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = runtime.EvalContext(goContext, `let never = channel<Int>(0)
+spawn(() => never.receive())
+spawn(() => {
+    while true {
+    }
+})`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, but got %v", err)
+	}
+}
+
+func TestLazyBindingWaitingOnChannelFromTasks(t *testing.T) {
+	//One task evaluates x and waits to receive, so the other has to let the timer run while it waits for x
+	finished := make(chan interface{})
+	go func() {
+		runtime := elara.New()
+		_, err := runtime.Eval(`let ch = channel<Int>(0)
+let init = () => ch.receive()
+let lazy x = init()
+let a = spawn(() => x)
+let b = spawn(() => x)
+setTimeout(() => ch.send(5), 10)`)
+		if err != nil {
+			finished <- err
+			return
+		}
+		result, err := runtime.Eval(`[a.await(), b.await()]`)
+		if err != nil {
+			finished <- err
+			return
+		}
+		finished <- result
+	}()
+	select {
+	case result := <-finished:
+		if expected := []interface{}{int64(5), int64(5)}; !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v but got %v", expected, result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Deadlocked while tasks waited for a lazy binding")
+	}
+}
+
+func TestUnawaitedTaskFailures(t *testing.T) {
+	evaluator, _ := runWithOptions(t, `spawn(() => exit(2))
+let reached = true`)
+	if exitCode, exited := evaluator.ExitCode(); !exited || exitCode != 2 {
+		t.Errorf("Expected a task's exit to stop the script with code 2, but got %d, %v", exitCode, exited)
+	}
+
+	runtime := elara.New(interpreter.WithPermissions(interpreter.NoPermissions()))
+	_, err := runtime.Eval(`spawn(() => env("HOME"))`)
+	var permissionError *interpreter.PermissionError
+	if !errors.As(err, &permissionError) {
+		t.Errorf("Expected a task's permission error to stop the script, but got %v", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	_, err = elara.New(interpreter.WithStderr(stderr)).Eval(`spawn(() => [1][3])
+spawn(() => [2][0]).await()
+let forever = spawn(() => {
+    while true {
+    }
+})
+forever.cancel()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Task failed: Index 3 out of bounds for collection of size 1\n"; stderr.String() != expected {
+		t.Errorf("Expected only the unawaited failure to be reported, but got %q", stderr)
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
 	"testing"
 )
 
@@ -23,8 +24,9 @@ let produceDaves(Int amount) => {
 
 let daves = produceDaves(2147483647)
 	`
-	base.LoadStdLib()
+	global := interpreter.NewGlobal()
+	base.LoadStdLib(global)
 	for i := 0; i < b.N; i++ {
-		base.Execute(nil, code, false)
+		base.ExecuteIn(global, nil, code, false)
 	}
 }
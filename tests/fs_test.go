@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileReadAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	code := strings.ReplaceAll(`namespace test/fs
+import elara/fs
+let file = joinPath("DIR", "notes.txt")
+writeText(file, "first")
+appendText(file, "\nsecond")
+readText(file).lines()
+writeBytes(joinPath("DIR", "data.bin"), [0, 127, 255])
+readBytes(joinPath("DIR", "data.bin"))
+stat(file).size
+list("DIR")`, "DIR", dir)
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("first"), interpreter.StringValue("second")}),
+		interpreter.UnitValue(),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(0), interpreter.IntValue(127), interpreter.IntValue(255)}),
+		interpreter.IntValue(12),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("data.bin"), interpreter.StringValue("notes.txt")}),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-5:], expectedResults) {
+		t.Errorf("Incorrect fs output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "notes.txt")); string(content) != "first\nsecond" {
+		t.Errorf("Incorrect file content %q", content)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	code := strings.ReplaceAll(`namespace test/fs
+import elara/fs
+readText("DIR/missing.txt") is Error
+mkdir("DIR/a/b")
+writeText("DIR/a/b/c.txt", "c")
+delete("DIR/a") is Error
+glob("DIR/*/*/*.txt").map((String path) => baseName(path))
+deleteAll("DIR/a")
+exists("DIR/a")`, "DIR", dir)
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.BooleanValue(true),
+		interpreter.UnitValue(),
+		interpreter.UnitValue(),
+		interpreter.BooleanValue(true),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("c.txt")}),
+		interpreter.UnitValue(),
+		interpreter.BooleanValue(false),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-7:], expectedResults) {
+		t.Errorf("Incorrect fs output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestGlobResolvesRelativePatterns(t *testing.T) {
+	code := `namespace test/fs
+import elara/fs
+glob("fs_*.go")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	absolute, _ := filepath.Abs("fs_test.go")
+	expected := interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue(absolute)})
+
+	if !reflect.DeepEqual(results[len(results)-1], expected) {
+		t.Errorf("Expected glob to give absolute paths, but got %v", formatValues(results))
+	}
+}
+
+func TestPaths(t *testing.T) {
+	code := `namespace test/fs
+import elara/fs
+joinPath("a/b", "../c")
+normalisePath("a//b/./c/..")
+baseName("/tmp/archive.tar.gz")
+dirName("/tmp/archive.tar.gz")
+extension("/tmp/archive.tar.gz")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("a/c"),
+		interpreter.StringValue("a/b"),
+		interpreter.StringValue("archive.tar.gz"),
+		interpreter.StringValue("/tmp"),
+		interpreter.StringValue(".gz"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-5:], expectedResults) {
+		t.Errorf("Incorrect path output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
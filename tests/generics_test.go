@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenericFunctionInference(t *testing.T) {
+	code := `<T>
+let identity = (T value) => T { value }
+<T>
+let first = ([T] list) => T { list[0] }
+identity(3)
+first([5, 6])
+empty<Int>() is [Int]
+empty<Int>() is [String]`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(3),
+		interpreter.IntValue(5),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect generic function output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestGenericTypeParameterInFunctionBody(t *testing.T) {
+	code := `<T>
+let emptyLike = (T sample) => [T] { empty<T>() }
+emptyLike(1) is [Int]
+emptyLike(1) is [Float]`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect generic function output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestGenericStruct(t *testing.T) {
+	code := `<T>
+struct Box {
+    T value
+}
+<T>
+let unbox = (Box<T> box) => T { box.value }
+let box = Box(3)
+box is Box<Int>
+box is Box<String>
+unbox(box)`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		nil,
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		interpreter.IntValue(3),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect generic struct output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestGenericMapLiteralTypes(t *testing.T) {
+	code := `let typed = {1: "a", 2: "b"}
+let mixed = {1: "a", "b": 2}
+typed is {Int : String}
+mixed is {Int : String}`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect map type output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func expectPanicContaining(t *testing.T, expected string) {
+	r := recover()
+	message, isString := r.(string)
+	if !isString || !strings.Contains(message, expected) {
+		t.Errorf("Expected an error containing %q, got %v", expected, r)
+	}
+}
+
+func TestGenericBoundIsEnforced(t *testing.T) {
+	defer expectPanicContaining(t, "does not satisfy the bound Int of type parameter T")
+
+	code := `<T: Int>
+let onlyInts = (T value) => T { value }
+onlyInts(3.5)`
+	base.Execute(nil, code, false)
+}
+
+func TestGenericConflictingInference(t *testing.T) {
+	defer expectPanicContaining(t, "Conflicting types for type parameter T")
+
+	code := `<T>
+let same = (T a, T b) => T { a }
+same(1, 2.5)`
+	base.Execute(nil, code, false)
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHTTPServer(t *testing.T) {
+	code := `namespace test/server
+import elara/http
+let greeting = "Hello "
+let handler = (ServerRequest request) => respond(200, greeting + request.query["name"]).withHeader("X-Path", request.path)
+let server = listen(0, handler)
+let url = "http://localhost:" + server.port().toString()
+let response = get(url + "/greet?name=Elara")
+response.status
+response.body
+response.header("X-Path")
+server.shutdown()
+server.wait()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(200),
+		interpreter.StringValue("Hello Elara"),
+		interpreter.StringValue("/greet"),
+		interpreter.UnitValue(),
+		interpreter.UnitValue(),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-5:], expectedResults) {
+		t.Errorf("Incorrect server output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHTTPRouter(t *testing.T) {
+	code := `namespace test/server
+import elara/http
+let getItem = route("GET", "/items/:id", (ServerRequest request) => respond(200, "item " + request.params["id"]))
+let createItem = route("POST", "/items", (ServerRequest request) => respondJSON(201, [request.body]))
+let fail = route("*", "/fail", (ServerRequest request) => respond(200, [1][5].toString()))
+let server = listen(0, router([getItem, createItem, fail]))
+let url = "http://localhost:" + server.port().toString()
+get(url + "/items/42").body
+post(url + "/items", "new").body
+post(url + "/items", "new").header("content-type")
+get(url + "/items").status
+get(url + "/fail").status
+server.shutdown()
+server.wait()`
+	stderr := &bytes.Buffer{}
+	_, results := runWithOptions(t, code, interpreter.WithStderr(stderr))
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("item 42"),
+		interpreter.StringValue(`["new"]`),
+		interpreter.StringValue("application/json"),
+		interpreter.IntValue(404),
+		interpreter.IntValue(500),
+		interpreter.UnitValue(),
+		interpreter.UnitValue(),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-7:], expectedResults) {
+		t.Errorf("Incorrect router output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+	if expected := "Error handling GET /fail: Index 5 out of bounds for collection of size 1\n"; stderr.String() != expected {
+		t.Errorf("Expected the failed request to be reported, but got %q", stderr)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	runtime := elara.New()
+	port, err := runtime.Eval(`namespace test/server
+import elara/http
+let handler = (ServerRequest request) => {
+    let mut total = 0
+    for i in 0..100 {
+        total = total + i
+    }
+    return respond(200, request.query["id"] + ":" + total.toString())
+}
+let server = listen(0, handler)
+server.port()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wait.Add(1)
+		go func(id int) {
+			defer wait.Done()
+			response, err := http.Get(fmt.Sprintf("http://localhost:%d/?id=%d", port, id))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(response.Body)
+			if expected := fmt.Sprintf("%d:4950", id); string(body) != expected {
+				t.Errorf("Expected %s but got %s", expected, body)
+			}
+		}(i)
+	}
+	wait.Wait()
+
+	if _, err := runtime.Eval(`server.shutdown()
+server.wait()`); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandlerExit(t *testing.T) {
+	code := `namespace test/server
+import elara/http
+let handler = (ServerRequest request) => {
+    exit(4)
+    return respond(200, "unreachable")
+}
+let server = listen(0, handler)
+get("http://localhost:" + server.port().toString()).status
+server.wait()
+let reached = true`
+	stderr := &bytes.Buffer{}
+	evaluator, results := runWithOptions(t, code, interpreter.WithStderr(stderr))
+	if results[len(results)-1] != nil {
+		t.Errorf("Expected the handler's exit to stop the script at wait, but got %v", formatValues(results))
+	}
+	if exitCode := evaluator.RunMain(); exitCode != 4 {
+		t.Errorf("Expected exit code 4 but got %d", exitCode)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected an exit not to be reported as an error, but got %q", stderr)
+	}
+}
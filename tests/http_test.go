@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + ":" + string(body)))
+	}))
+}
+
+func TestHTTPRequests(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+	code := strings.ReplaceAll(`namespace test/http
+import elara/http
+let response = request("PUT", "URL/items").withHeader("authorization", "token").withBody("hello").send()
+response.status
+response.body
+response.header("x-method")
+get("URL/missing").status
+get("URL/missing").isSuccess()
+post("URL", "data").body`, "URL", server.URL)
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(200),
+		interpreter.StringValue("token:hello"),
+		interpreter.StringValue("PUT"),
+		interpreter.IntValue(404),
+		interpreter.BooleanValue(false),
+		interpreter.StringValue(":data"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-6:], expectedResults) {
+		t.Errorf("Incorrect http output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHTTPJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"contentType": "` + r.Header.Get("Content-Type") + `", "received": ` + string(body) + `}`))
+	}))
+	defer server.Close()
+	code := strings.ReplaceAll(`namespace test/http
+import elara/http
+let response = request("POST", "URL").withJSON([1, 2, 3]).send()
+let parsed = response.json()
+parsed["contentType"]
+parsed["received"]`, "URL", server.URL)
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("application/json"),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(1), interpreter.IntValue(2), interpreter.IntValue(3)}),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect http output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	server := newEchoServer()
+	url := server.URL
+	server.Close()
+	slowServer := newEchoServer()
+	defer slowServer.Close()
+
+	code := strings.ReplaceAll(strings.ReplaceAll(`namespace test/http
+import elara/http
+get("CLOSED") is Error
+request("GET", "SLOW/slow").withTimeout(20).send() is Error
+request("GET", "SLOW/slow").send() is Response`, "CLOSED", url), "SLOW", slowServer.URL)
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-3:], expectedResults) {
+		t.Errorf("Incorrect http output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHTTPMaxResponseSize(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+	code := strings.ReplaceAll(`namespace test/http
+import elara/http
+post("URL", "abc").body
+post("URL", "abcd").message`, "URL", server.URL)
+	_, results := runWithOptions(t, code, interpreter.WithMaxResponseSize(4))
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue(":abc"),
+		interpreter.StringValue("response body is larger than 4 bytes"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect http output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHTTPRedirectPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/local":
+			http.Redirect(w, r, "/done", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("done"))
+		}
+	}))
+	defer server.Close()
+
+	runtime := elara.New(interpreter.WithPermissions(&interpreter.Permissions{Net: []string{"127.0.0.1"}}))
+	result, err := runtime.Eval(strings.ReplaceAll(`namespace test/http
+import elara/http
+get("URL/local").body`, "URL", server.URL))
+	if err != nil || result != "done" {
+		t.Errorf("Expected a redirect to an allowed host to be followed, but got %v, %v", result, err)
+	}
+
+	_, err = runtime.Eval(strings.ReplaceAll(`get("URL/away")`, "URL", server.URL))
+	var permissionError *interpreter.PermissionError
+	if !errors.As(err, &permissionError) || permissionError.Resource != "example.com" {
+		t.Errorf("Expected a redirect to example.com to be denied, but got %v", err)
+	}
+}
+
+func TestHTTPRequestIsCancelledWithScript(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	goContext, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := elara.New().EvalContext(goContext, strings.ReplaceAll(`namespace test/http
+import elara/http
+get("URL")
+while true {
+}`, "URL", server.URL))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to stop the script, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to stop with the script, but it took %v", elapsed)
+	}
+}
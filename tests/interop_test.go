@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ElaraLang/elara/elara"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string
+}
+
+type customer struct {
+	Name    string
+	Orders  []int
+	Address address
+	notes   string
+}
+
+func (c *customer) Total() int {
+	total := 0
+	for _, order := range c.Orders {
+		total += order
+	}
+	return total
+}
+
+func (c customer) Greeting(greeting string) string {
+	return greeting + ", " + c.Name
+}
+
+func (c *customer) Refund(amount int) error {
+	if amount > c.Total() {
+		return errors.New("refund is more than the total")
+	}
+	c.Orders = append(c.Orders, -amount)
+	return nil
+}
+
+func TestGoStructFieldsAndMethods(t *testing.T) {
+	runtime := elara.New()
+	ada := &customer{Name: "Ada", Orders: []int{10, 25}}
+	if err := runtime.Set("ada", ada); err != nil {
+		t.Fatal(err)
+	}
+	result, err := runtime.Eval(`let refused = ada.Refund(100)
+ada.Refund(5)
+ada.Name = "Ada Lovelace"
+ada.Address.City = "London"
+[ada.Greeting("Hello"), ada.Total(), ada.Orders.size, refused.message]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"Hello, Ada Lovelace", int64(30), int64(3), "refund is more than the total"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+	//Pointers are shared, so Elara changes the Go program's struct
+	if ada.Name != "Ada Lovelace" || ada.Address.City != "London" {
+		t.Errorf("Expected the fields to be set, but got %+v", ada)
+	}
+
+	failures := map[string]string{
+		`ada.notes`:       "Unknown property or extension",
+		`ada.notes = "x"`: "has no exported field notes",
+		`ada.Name = 5`:    "cannot convert 5",
+		`let total = 0
+total.size = 1`: "only the fields of Go values can be set",
+	}
+	for code, message := range failures {
+		_, err := runtime.Eval(code)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %q to fail with %q but got %v", code, message, err)
+		}
+	}
+}
+
+func TestGoTypes(t *testing.T) {
+	runtime := elara.New()
+	runtime.RegisterType("Customer", reflect.TypeOf(customer{}))
+	runtime.RegisterType("Stringer", reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+	err := runtime.Register("newCustomer", func(name string) customer {
+		return customer{Name: name, Orders: []int{1, 2}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runtime.Register("describe", func(c *customer) string {
+		return fmt.Sprintf("%s ordered %d", c.Name, c.Total())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := runtime.Eval(`let bob = newCustomer("Bob")
+extend Customer {
+    let shout = () => this.Name.toUpper()
+}
+[bob is Customer, "Bob" is Customer, bob is Stringer, bob.shout(), describe(bob)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{true, false, false, "BOB", "Bob ordered 3"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+
+	//Structs are given back to Go as a pointer
+	bob, _, _ := runtime.Global("bob")
+	if asCustomer, isCustomer := bob.(*customer); !isCustomer || asCustomer.Name != "Bob" {
+		t.Errorf("Expected a *customer but got %v", bob)
+	}
+
+	_, err = runtime.Eval(`describe("Bob")`)
+	if err == nil {
+		t.Error("Expected a String not to be accepted as a *customer")
+	}
+}
+
+type point struct {
+	X int
+	Y int
+}
+
+type tagged struct {
+	Value interface{}
+}
+
+func TestGoValueEquality(t *testing.T) {
+	runtime := elara.New()
+	values := map[string]interface{}{
+		"first":        point{1, 2},
+		"second":       point{1, 2},
+		"third":        point{2, 1},
+		"holdsMap":     tagged{Value: map[string]int{}},
+		"alsoHoldsMap": tagged{Value: map[string]int{}},
+	}
+	for name, value := range values {
+		if err := runtime.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//Each struct is copied when it is set, so these are compared by their fields
+	result, err := runtime.Eval(`let table = {first: "found"}
+[first == second, first == third, holdsMap == alsoHoldsMap, holdsMap == holdsMap, table[second]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{true, false, false, true, "found"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+}
+
+func TestGoPackages(t *testing.T) {
+	runtime := elara.New()
+	err := runtime.RegisterPackage("go/strings", map[string]interface{}{
+		"Repeat":   strings.Repeat,
+		"Fields":   strings.Fields,
+		"Replacer": reflect.TypeOf((*strings.Replacer)(nil)),
+		"Dashes":   strings.NewReplacer(" ", "-"),
+		"Sizes":    map[string]int{"small": 1, "large": 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := runtime.Eval(`namespace test/interop
+import go/strings
+[Repeat("ab", 2), Fields(" a b  c ").size, Dashes is Replacer, Dashes.Replace("x y z"), Sizes["large"]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"abab", int64(3), true, "x-y-z", int64(3)}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+
+	if _, err := elara.New().Eval(`namespace test/interop
+import go/strings`); err == nil {
+		t.Error("Expected packages not to be shared between runtimes")
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/ElaraLang/elara/interpreter"
+	"strings"
+	"testing"
+)
+
+func TestOutputStreams(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	runWithOptions(t, `stdout.write("out ")
+stderr.write("err")
+stdout.write(3)`, interpreter.WithStdout(stdout), interpreter.WithStderr(stderr))
+	if stdout.String() != "out 3" {
+		t.Errorf("Incorrect stdout, got %q", stdout)
+	}
+	if stderr.String() != "err" {
+		t.Errorf("Incorrect stderr, got %q", stderr)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	code := `let first = readLine()
+let second = input()
+stdout.write(first + "|" + second + "|")
+stdout.write(readLine() is Unit)`
+	stdout := &bytes.Buffer{}
+	runWithOptions(t, code, interpreter.WithStdin(strings.NewReader("two words\r\nthird\n")), interpreter.WithStdout(stdout))
+	if stdout.String() != "two words|third|true" {
+		t.Errorf("Incorrect stdout, got %q", stdout)
+	}
+}
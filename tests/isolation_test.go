@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"github.com/ElaraLang/elara/lexer"
+	"github.com/ElaraLang/elara/parser"
+	"sync"
+	"testing"
+)
+
+func TestInterpretersDoNotShareNamespaces(t *testing.T) {
+	base.Execute(nil, `namespace isolated/lib
+let secret = 1`, false)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected importing a namespace from another interpreter to fail")
+		}
+	}()
+	base.Execute(nil, `namespace isolated/user
+import isolated/lib
+secret`, false)
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			//Every script declares the same namespace and struct, but with its own values
+			code := fmt.Sprintf(`namespace concurrent/script
+import elara/json
+struct Request {
+    Int id
+}
+let request = decode<Request>("{\"id\": %d}")
+let mut total = 0
+for n in 0..100 {
+    total = total + request.id
+}
+stdout.write(stringify([request.id, total]))`, i)
+			statements, errs := parser.NewParser(lexer.Lex(code)).Parse()
+			if len(errs) != 0 {
+				t.Errorf("Syntax errors: %v", errs)
+				return
+			}
+			out := &bytes.Buffer{}
+			interpreter.NewInterpreter(statements, interpreter.WithStdout(out)).Exec(false)
+
+			expected := fmt.Sprintf("[%d,%d]", i, i*100)
+			if out.String() != expected {
+				t.Errorf("Expected %s but got %s", expected, out.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestJSONParse(t *testing.T) {
+	code := `namespace test/json
+import elara/json
+let parsed = parse("{\"name\": \"Elara\", \"tags\": [\"a\", \"b\"], \"version\": 2, \"ratio\": 0.5, \"stable\": false}")
+parsed["name"]
+parsed["tags"]
+parsed["version"]
+parsed["ratio"]
+parsed["stable"]
+parse("[1, 2") is Error`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("Elara"),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("a"), interpreter.StringValue("b")}),
+		interpreter.IntValue(2),
+		interpreter.FloatValue(0.5),
+		interpreter.BooleanValue(false),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-6:], expectedResults) {
+		t.Errorf("Incorrect JSON parse output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	code := `namespace test/json
+import elara/json
+struct Point {
+    Int y
+    Int x
+    String label = "origin"
+}
+stringify(Point(2, 1))
+stringify({"b": [1.0, 2], "a": "say \"hi\""})
+stringify(parse("{\"z\": null, \"a\": true}"))`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue(`{"y":2,"x":1,"label":"origin"}`),
+		interpreter.StringValue(`{"b":[1.0,2],"a":"say \"hi\""}`),
+		interpreter.StringValue(`{"z":null,"a":true}`),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-3:], expectedResults) {
+		t.Errorf("Incorrect JSON stringify output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestJSONDecode(t *testing.T) {
+	code := `namespace test/json
+import elara/json
+struct Owner {
+    String name
+}
+struct Repository {
+    String name
+    Int stars
+    Owner owner
+    Float score = 1.0
+}
+let repository = decode<Repository>("{\"name\": \"elara\", \"stars\": 3, \"owner\": {\"name\": \"bristermitten\"}, \"ignored\": 1}")
+repository.owner.name
+repository.score
+decode<Repository>("{\"name\": \"elara\", \"stars\": \"many\", \"owner\": {}}").message
+decode<Repository>("{\"name\": \"elara\", \"stars\": 3, \"owner\": {}}").message`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("bristermitten"),
+		interpreter.FloatValue(1),
+		interpreter.StringValue("Cannot decode JSON into Repository: expected Int at stars but found many"),
+		interpreter.StringValue("Cannot decode JSON into Repository: missing property owner.name"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:], expectedResults) {
+		t.Errorf("Incorrect JSON decode output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestJSONStringifyRestrictedFields(t *testing.T) {
+	global := interpreter.NewGlobal()
+	base.ExecuteIn(global, nil, restrictedLibrary, false)
+	code := `namespace test/json
+import elara/json
+import restricted/lib
+stringify(Secret(1, 2))`
+	results, _, _, _ := base.ExecuteIn(global, nil, code, false)
+	if expected := interpreter.StringValue(`{"shown":1}`); !reflect.DeepEqual(results[len(results)-1], expected) {
+		t.Errorf("Expected the restricted field to be left out, but got %v", formatValues(results))
+	}
+}
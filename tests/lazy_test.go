@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyBindingIsEvaluatedOnceOnFirstAccess(t *testing.T) {
+	code := `let mut count = 0
+let compute = () => {
+    count = count + 1
+    42
+}
+let lazy x = compute()
+count
+x
+x
+count`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		nil,
+		interpreter.IntValue(0),
+		interpreter.IntValue(42),
+		interpreter.IntValue(42),
+		interpreter.IntValue(1),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect lazy evaluation, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestLazyBindingWithInvalidType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Interpreter allows lazy binding of incorrect type")
+		}
+	}()
+
+	code := `let lazy a: Int = 3.5
+	a`
+	base.Execute(nil, code, false)
+}
+
+func TestLazyBindingCycle(t *testing.T) {
+	defer func() {
+		r := recover()
+		message, isString := r.(string)
+		if !isString || !strings.Contains(message, "a -> b -> a") {
+			t.Errorf("Expected a cycle error, got %v", r)
+		}
+	}()
+
+	code := `let lazy a = b
+let lazy b = a
+a`
+	base.Execute(nil, code, false)
+}
+
+func TestLazyValueConcurrentAccess(t *testing.T) {
+	var evaluations int32
+	initializer := interpreter.NewAbstractCommand(func(ctx *interpreter.Context) *interpreter.ReturnedValue {
+		atomic.AddInt32(&evaluations, 1)
+		return interpreter.NonReturningValue(interpreter.IntValue(3))
+	})
+	ctx := interpreter.NewGlobal().NewContext(false)
+	lazy := interpreter.NewLazyValue("x", initializer, ctx, interpreter.IntType)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if lazy.Force(ctx).Value != int64(3) {
+				t.Errorf("Incorrect lazy value")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if evaluations != 1 {
+		t.Errorf("Lazy value was evaluated %d times", evaluations)
+	}
+}
+
+func TestLazyBindingIsNotForcedByFunctionLookup(t *testing.T) {
+	code := `let mut count = 0
+let compute = () => {
+    count = count + 1
+    42
+}
+let twice = (Int this) => this * 2
+let check = () => {
+    let inner = () => {
+        let lazy twice = compute()
+        let three = 3
+        three.twice()
+    }
+    inner()
+}
+check()
+count`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(6),
+		interpreter.IntValue(0),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Looking up twice forced the lazy binding, got %v but expected %v", formatValues(results[len(results)-2:]), formatValues(expectedResults))
+	}
+}
+
+func TestLazyBindingOutlivesItsScope(t *testing.T) {
+	code := `let makeGreeting = (String name) => {
+    let lazy greeting = prefix + name
+    let prefix = "Hello "
+    () => greeting
+}
+let reuseScopes = (String name) => {
+    let prefix = "Goodbye "
+    prefix + name
+}
+let greet = makeGreeting("Bob")
+reuseScopes("Alice")
+greet()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("Goodbye Alice"),
+		interpreter.StringValue("Hello Bob"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect lazy binding output, got %v but expected %v", formatValues(results[len(results)-2:]), formatValues(expectedResults))
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepLimit(t *testing.T) {
+	runtime := elara.New(interpreter.WithStepLimit(1000))
+	result, err := runtime.Eval(`let mut total = 0
+for i in 0..100 {
+    total = total + i
+}
+total`)
+	if err != nil || result != int64(4950) {
+		t.Fatalf("Expected a short loop to finish, but got %v, %v", result, err)
+	}
+
+	_, err = runtime.Eval(`while true {
+    total = total + 1
+}`)
+	if !errors.Is(err, interpreter.ErrStepLimitExceeded) {
+		t.Errorf("Expected the step limit to be exceeded, but got %v", err)
+	}
+}
+
+func TestStepLimitIsShared(t *testing.T) {
+	//Every task would finish within the limit on its own, but together they go over it
+	runtime := elara.New(interpreter.WithStepLimit(100))
+	_, err := runtime.Eval(`let work = () => {
+    let mut total = 0
+    for i in 0..30 {
+        total = total + i
+    }
+    total
+}
+let tasks = [spawn(work), spawn(work), spawn(work), spawn(work), spawn(work)]
+tasks.map((Task task) => task.await())
+work()`)
+	if !errors.Is(err, interpreter.ErrStepLimitExceeded) {
+		t.Errorf("Expected the tasks to share the step limit, but got %v", err)
+	}
+
+	runtime = elara.New(interpreter.WithStepLimit(100))
+	_, err = runtime.Eval("[" + strings.Repeat("1, ", 200) + "1].parallelMap((Int x) => x * 2)")
+	if !errors.Is(err, interpreter.ErrStepLimitExceeded) {
+		t.Errorf("Expected the parallel workers to share the step limit, but got %v", err)
+	}
+}
+
+func TestStepLimitCountsNativeWork(t *testing.T) {
+	//Each of these is a single call, but would take far longer than 1000 steps' worth of work
+	for _, code := range []string{`"ab" * 100000000`, `[1, 2] * 100000000`} {
+		runtime := elara.New(interpreter.WithStepLimit(1000))
+		_, err := runtime.Eval(code)
+		if !errors.Is(err, interpreter.ErrStepLimitExceeded) {
+			t.Errorf("Expected %s to exceed the step limit, but got %v", code, err)
+		}
+	}
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	runtime := elara.New(interpreter.WithMaxCallDepth(50))
+	_, err := runtime.Eval(`let recurse = (Int n) => recurse(n + 1)
+recurse(0)`)
+	if !errors.Is(err, interpreter.ErrCallDepthExceeded) {
+		t.Errorf("Expected the call depth limit to be exceeded, but got %v", err)
+	}
+	var limitError *interpreter.LimitError
+	if !errors.As(err, &limitError) {
+		t.Errorf("Expected a LimitError, but got %T", err)
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	runtime := elara.New()
+	goContext, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	finished := make(chan error)
+	go func() {
+		_, err := runtime.EvalContext(goContext, `while true {
+}`)
+		finished <- err
+	}()
+
+	select {
+	case err := <-finished:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the deadline to be exceeded, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execution was not stopped by the deadline")
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestForLoops(t *testing.T) {
+	code := `let mut total = 0
+for i in 0..5 {
+    total = total + i
+}
+for x in [1, 2, 3] {
+    if x == 2 {
+        continue
+    }
+    total = total + x
+}
+let m = {1: 10, 2: 20}
+for (k, v) in m {
+    total = total + k * v
+}
+total`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expected := interpreter.IntValue(64)
+
+	if !reflect.DeepEqual(results[len(results)-1], expected) {
+		t.Errorf("Incorrect for loop output, got %v but expected %v", formatValues(results), expected)
+	}
+}
+
+func TestLabelledBreakAndContinue(t *testing.T) {
+	code := `let mut count = 0
+outer: for a in 0..3 {
+    for b in 0..3 {
+        if b == 1 {
+            continue outer
+        }
+        if a == 2 {
+            break outer
+        }
+        count = count + 1
+    }
+}
+let mut n = 0
+while true {
+    n = n + 1
+    if n == 10 {
+        break
+    }
+}
+count
+n`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(2),
+		interpreter.IntValue(10),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect loop output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestReturnInsideLoop(t *testing.T) {
+	code := `let find = (Int target) => Int {
+    for i in 0..100 {
+        if i == target {
+            return i * 2
+        }
+    }
+    return 0
+}
+let forever = (Int p) => Int {
+    while true {
+        return p
+    }
+    0
+}
+find(7)
+forever(9)`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(14),
+		interpreter.IntValue(9),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect loop output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestBreakOutsideLoop(t *testing.T) {
+	defer expectPanicContaining(t, "Cannot break outside of a loop")
+	base.Execute(nil, "break", false)
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestMapPersistence(t *testing.T) {
+	code := `let m = {"a": 1, "b": 2}
+let added = m.put("c", 3).put("a", 10)
+let removed = added.remove("b")
+m["a"]
+m.size
+added["a"]
+removed.containsKey("b")
+added.containsKey("b")
+removed.getOrDefault("z", 99)
+removed.keys`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		nil,
+		interpreter.IntValue(1),
+		interpreter.IntValue(2),
+		interpreter.IntValue(10),
+		interpreter.BooleanValue(false),
+		interpreter.BooleanValue(true),
+		interpreter.IntValue(99),
+	}
+
+	if !reflect.DeepEqual(results[:len(results)-1], expectedResults) {
+		t.Errorf("Incorrect map output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+	if keys := results[len(results)-1].String(); keys != "[a, c]" {
+		t.Errorf("Map keys were not in insertion order, got %s", keys)
+	}
+}
+
+func TestStructAndCollectionKeys(t *testing.T) {
+	code := `struct Point {
+    Int x
+    Int y
+}
+let points = {Point(1, 2): "p"}
+let lists = {[1, 2]: "l"}
+points[Point(1, 2)]
+points.containsKey(Point(1, 3))
+lists[[1, 2]]
+lists.containsKey([1, 3])`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		nil,
+		interpreter.StringValue("p"),
+		interpreter.BooleanValue(false),
+		interpreter.StringValue("l"),
+		interpreter.BooleanValue(false),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect map output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestHashCodeExtension(t *testing.T) {
+	code := `struct Id {
+    Int value
+    String label
+}
+extend Id {
+    let hashCode => Int { this.value }
+    let equals = (Id other) => Boolean { this.value == other.value }
+}
+let ids = {Id(1, "a"): "first"}
+Id(1, "b").hashCode()
+ids[Id(1, "b")]`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(1),
+		interpreter.StringValue("first"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect map output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestLargeMap(t *testing.T) {
+	code := `let mut table = {0: 0}
+for i in 0..100000 {
+    table = table.put(i, i * 2)
+}
+table[99999]
+table.size`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(199998),
+		interpreter.IntValue(100000),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect map output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestMapBuilder(t *testing.T) {
+	code := `let builder = mapBuilder()
+for i in 0..1000 {
+    builder.put(i, i * 2)
+}
+builder.remove(0)
+let built = builder.build()
+builder.put(0, 0)
+built.containsKey(0)
+built[999]
+built.size
+builder.size`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.BooleanValue(false),
+		interpreter.IntValue(1998),
+		interpreter.IntValue(999),
+		interpreter.IntValue(1000),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:], expectedResults) {
+		t.Errorf("Incorrect map builder output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestMissingMapKey(t *testing.T) {
+	code := `let table = {1: "a"}
+table[2]`
+	results, _, _, _ := base.Execute(nil, code, false)
+
+	if results[len(results)-1] != nil {
+		t.Errorf("Expected a missing key to give nil, but got %s", results[len(results)-1])
+	}
+}
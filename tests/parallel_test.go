@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"strings"
+	"testing"
+)
+
+func TestParallelCollectionFunctions(t *testing.T) {
+	code := `let builder = listBuilder()
+for i in 1..201 {
+    builder.add(i)
+}
+let numbers = builder.build()
+let offset = 1000
+let squares = numbers.parallelMap((Int x) => x * x + offset)
+squares[0]
+squares[199]
+numbers.parallelFilter((Int x) => x % 50 == 0)
+numbers.parallelFold(0, (Int a, Int b) => a + b)
+["a", "bb", "ccc"].parallelFold(0, (Int count, String word) => count + word.size, (Int a, Int b) => a + b)
+[1, 2, 3].parallelMap((Int x) => [10, 20].map((Int y) => x * y))`
+	results, _, _, _ := base.Execute(nil, code, false)
+	multiples := interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(50), interpreter.IntValue(100), interpreter.IntValue(150), interpreter.IntValue(200)})
+	products := interpreter.CollectionValue(interpreter.AnyType, []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(10), interpreter.IntValue(20)}),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(20), interpreter.IntValue(40)}),
+		interpreter.CollectionValue(interpreter.IntType, []*interpreter.Value{interpreter.IntValue(30), interpreter.IntValue(60)}),
+	})
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(1001),
+		interpreter.IntValue(41000),
+		multiples,
+		interpreter.IntValue(20100),
+		interpreter.IntValue(6),
+		products,
+	}
+
+	for i, expected := range expectedResults {
+		result := results[len(results)-len(expectedResults)+i]
+		if result.String() != expected.String() {
+			t.Errorf("Incorrect result %d, got %s but expected %s", i, result, expected)
+		}
+	}
+}
+
+func TestParallelFunctionsMustBePure(t *testing.T) {
+	scripts := map[string]string{
+		"reassigns total": `let mut total = 0
+[1, 2].parallelMap((Int x) => total = total + x)`,
+		"uses add, which reassigns total": `let mut total = 0
+let add = (Int x) => total = total + x
+[1, 2].parallelFilter((Int x) => add(x) == 1)`,
+		"write has side effects": `[1, 2].parallelMap((Int x) => stdout.write(x))`,
+		//The function called isn't known until it runs, so it is stopped when it is called
+		"Cannot reassign total from a parallel function": `let mut total = 0
+let functions = [(Int x) => total = total + x]
+[1, 2].parallelFold(0, (Int a, Int x) => functions[0](x))`,
+	}
+	for expected, script := range scripts {
+		runtime := elara.New()
+		_, err := runtime.Eval(script)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q, but got %v", expected, err)
+		}
+	}
+}
+
+func TestParallelFirstErrorIsReturned(t *testing.T) {
+	runtime := elara.New()
+	for i := 0; i < 20; i++ {
+		//Every element after the third fails, but the first failure in order is always the one returned
+		_, err := runtime.Eval(`[0, 1, 2, 3, 4, 5, 6, 7, 8, 9].parallelMap((Int x) => [1, 2, 3][x])`)
+		if err == nil || !strings.Contains(err.Error(), "Index 3 out of bounds") {
+			t.Fatalf("Expected the first out of bounds index to fail, but got %v", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNoPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	_ = ioutil.WriteFile(path, []byte("secret"), 0644)
+
+	scripts := map[string]string{
+		"read":  `readText("FILE")`,
+		"write": `writeText("FILE", "changed")`,
+		"env":   `env("HOME")`,
+		"net":   `get("http://example.com")`,
+	}
+	for permission, script := range scripts {
+		runtime := elara.New(interpreter.WithPermissions(interpreter.NoPermissions()))
+		_, err := runtime.Eval("namespace test/permissions\nimport elara/fs\nimport elara/http\n" + strings.ReplaceAll(script, "FILE", path))
+		var permissionError *interpreter.PermissionError
+		if !errors.As(err, &permissionError) || permissionError.Permission != permission {
+			t.Errorf("Expected %s to be denied, but got %v", permission, err)
+		}
+	}
+	if content, _ := ioutil.ReadFile(path); string(content) != "secret" {
+		t.Errorf("File was changed without permission: %q", content)
+	}
+}
+
+func TestPathPermissions(t *testing.T) {
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed")
+	_ = ioutil.WriteFile(filepath.Join(dir, "allowedButNotReally.txt"), []byte("no"), 0644)
+	runtime := elara.New(interpreter.WithPermissions(&interpreter.Permissions{
+		Read:  []string{allowed},
+		Write: []string{allowed},
+	}))
+
+	result, err := runtime.Eval(strings.ReplaceAll(`namespace test/permissions
+import elara/fs
+mkdir(joinPath("DIR", "notes"))
+writeText(joinPath("DIR", "notes/a.txt"), "hello")
+readText(joinPath("DIR", "notes/../notes/a.txt"))`, "DIR", allowed))
+	if err != nil || result != "hello" {
+		t.Fatalf("Expected to use the allowed directory, but got %v, %v", result, err)
+	}
+
+	//A path that starts with the same text, but isn't inside the allowed directory
+	_, err = runtime.Eval(`readText("` + allowed + `ButNotReally.txt")`)
+	var permissionError *interpreter.PermissionError
+	if !errors.As(err, &permissionError) {
+		t.Errorf("Expected reading outside the allowed directory to be denied, but got %v", err)
+	}
+
+	//Links inside the allowed directory that lead outside it, including to a file that doesn't exist yet
+	outside := filepath.Join(dir, "outside")
+	_ = os.Mkdir(outside, 0755)
+	_ = ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	_ = os.Symlink(outside, filepath.Join(allowed, "link"))
+	_ = os.Symlink(filepath.Join(outside, "created.txt"), filepath.Join(allowed, "dangling.txt"))
+	scripts := []string{
+		`readText("DIR/link/secret.txt")`,
+		`writeText("DIR/link/new.txt", "escaped")`,
+		`writeText("DIR/dangling.txt", "escaped")`,
+	}
+	for _, script := range scripts {
+		_, err = runtime.Eval(strings.ReplaceAll(script, "DIR", allowed))
+		if !errors.As(err, &permissionError) {
+			t.Errorf("Expected %s to be denied, but got %v", script, err)
+		}
+	}
+	if files, _ := ioutil.ReadDir(outside); len(files) != 1 {
+		t.Errorf("Expected nothing to be written outside the allowed directory, but found %d files", len(files))
+	}
+}
+
+func TestEnvPermissions(t *testing.T) {
+	t.Setenv("ELARA_ALLOWED", "yes")
+	t.Setenv("ELARA_DENIED", "no")
+	runtime := elara.New(interpreter.WithPermissions(&interpreter.Permissions{
+		Env: []string{"ELARA_ALLOWED"},
+	}))
+
+	result, err := runtime.Eval(`env("ELARA_ALLOWED")`)
+	if err != nil || result != "yes" {
+		t.Errorf("Expected to read an allowed variable, but got %v, %v", result, err)
+	}
+	_, err = runtime.Eval(`env("ELARA_DENIED")`)
+	var permissionError *interpreter.PermissionError
+	if !errors.As(err, &permissionError) || permissionError.Resource != "ELARA_DENIED" {
+		t.Errorf("Expected reading ELARA_DENIED to be denied, but got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/interpreter"
+	"github.com/ElaraLang/elara/lexer"
+	"github.com/ElaraLang/elara/parser"
+	"reflect"
+	"testing"
+)
+
+//runWithOptions runs code in a new interpreter, so that tests can give it options such as its arguments or streams
+func runWithOptions(t *testing.T, code string, options ...interpreter.Option) (*interpreter.Interpreter, []*interpreter.Value) {
+	statements, errs := parser.NewParser(lexer.Lex(code)).Parse()
+	if len(errs) != 0 {
+		t.Fatalf("Syntax errors: %v", errs)
+	}
+	evaluator := interpreter.NewInterpreter(statements, options...)
+	return evaluator, evaluator.Exec(false)
+}
+
+func TestArgsAndEnv(t *testing.T) {
+	t.Setenv("ELARA_TEST_VARIABLE", "set")
+	code := `args
+args.size
+env("ELARA_TEST_VARIABLE")
+env("ELARA_TEST_MISSING") is Unit`
+	_, results := runWithOptions(t, code, interpreter.WithArgs([]string{"a", "b"}))
+	expectedResults := []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("a"), interpreter.StringValue("b")}),
+		interpreter.IntValue(2),
+		interpreter.StringValue("set"),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect process output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestExit(t *testing.T) {
+	code := `let mut reached = 1
+exit(3)
+reached = 2`
+	evaluator, results := runWithOptions(t, code)
+	if results[2] != nil {
+		t.Errorf("Expected execution to stop at exit, but got %v", formatValues(results))
+	}
+	if exitCode := evaluator.RunMain(); exitCode != 3 {
+		t.Errorf("Expected exit code 3 but got %d", exitCode)
+	}
+}
+
+func TestMainFunction(t *testing.T) {
+	code := `let main = ([String] arguments) => {
+    return arguments.size + 40
+}`
+	evaluator, _ := runWithOptions(t, code, interpreter.WithArgs([]string{"x", "y"}))
+	if exitCode := evaluator.RunMain(); exitCode != 42 {
+		t.Errorf("Expected exit code 42 but got %d", exitCode)
+	}
+
+	evaluator, _ = runWithOptions(t, `let main = () => stdout.write("")`)
+	if exitCode := evaluator.RunMain(); exitCode != 0 {
+		t.Errorf("Expected exit code 0 but got %d", exitCode)
+	}
+}
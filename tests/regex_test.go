@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestRegexMatching(t *testing.T) {
+	code := `namespace test/regex
+import elara/regex
+let pair = re"([0-9]+)-(?P<word>[a-z]+)"
+let first = pair.find("xx 12-ab yy 3-c")
+pair.matches("12-ab")
+pair.matches("x12-ab")
+first.value
+first.start
+first.groups
+first.named["word"]
+pair.findAll("12-ab yy 3-c").map((Match m) => m.value)`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		interpreter.StringValue("12-ab"),
+		interpreter.IntValue(3),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("12"), interpreter.StringValue("ab")}),
+		interpreter.StringValue("ab"),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("12-ab"), interpreter.StringValue("3-c")}),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-7:], expectedResults) {
+		t.Errorf("Incorrect regex output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestRegexReplaceAndSplit(t *testing.T) {
+	code := `namespace test/regex
+import elara/regex
+let pair = re"([0-9]+)-([a-z]+)"
+pair.replace("a 1-b c 22-d", (Match m) => m.groups[1].toUpper())
+pair.replace("a 1-b", "<$2>")
+re",\s*".split("a, b,c")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("a B c D"),
+		interpreter.StringValue("a <b>"),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("a"), interpreter.StringValue("b"), interpreter.StringValue("c")}),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-3:], expectedResults) {
+		t.Errorf("Incorrect regex output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestRegexErrors(t *testing.T) {
+	code := `namespace test/regex
+import elara/regex
+let invalid = compile("a(")
+let valid = compile("a+")
+invalid is Error
+valid is Regex
+valid.matches("aaa")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-3:], expectedResults) {
+		t.Errorf("Incorrect regex output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+
+	results, _, _, _ = base.Execute(nil, `let invalid = re"a("`, false)
+	if len(results) != 0 {
+		t.Errorf("Invalid regex literal was not rejected when parsing")
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+var restrictedLibrary = `namespace restricted/lib
+
+let visible = () => helper + 1
+let restricted helper = 2
+struct Secret {
+    Int shown
+    restricted Int hidden
+}
+let peek = (Secret s) => s.hidden
+`
+
+func TestRestrictedBindingAccessibleInsideNamespace(t *testing.T) {
+	global := interpreter.NewGlobal()
+	base.ExecuteIn(global, nil, restrictedLibrary, false)
+	code := `namespace restricted/inside
+import restricted/lib
+
+visible()
+peek(Secret(1, 2))
+Secret(1, 2).shown
+let helper = 5
+helper`
+	results, _, _, _ := base.ExecuteIn(global, nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(3),
+		interpreter.IntValue(2),
+		interpreter.IntValue(1),
+		nil,
+		interpreter.IntValue(5),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect restricted access output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestRestrictedBindingFromImportingNamespace(t *testing.T) {
+	global := interpreter.NewGlobal()
+	base.ExecuteIn(global, nil, restrictedLibrary, false)
+	defer expectPanicContaining(t, "helper is restricted to namespace restricted/lib")
+
+	code := `namespace restricted/binding
+import restricted/lib
+
+helper`
+	base.ExecuteIn(global, nil, code, false)
+}
+
+func TestRestrictedFieldFromImportingNamespace(t *testing.T) {
+	global := interpreter.NewGlobal()
+	base.ExecuteIn(global, nil, restrictedLibrary, false)
+	defer expectPanicContaining(t, "Secret::hidden is restricted to namespace restricted/lib")
+
+	code := `namespace restricted/field
+import restricted/lib
+
+Secret(1, 2).hidden`
+	base.ExecuteIn(global, nil, code, false)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"errors"
+	"github.com/ElaraLang/elara/elara"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeEvalAndCall(t *testing.T) {
+	runtime := elara.New()
+	result, err := runtime.Eval(`let discount = (Int total, [String] tags) => {
+    if tags.any((String tag) => tag == "vip") {
+        return total / 10
+    }
+    return 0
+}
+let threshold = 100
+threshold * 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != int64(200) {
+		t.Errorf("Expected 200 but got %v", result)
+	}
+
+	threshold, exists, err := runtime.Global("threshold")
+	if err != nil || !exists || threshold != int64(100) {
+		t.Errorf("Expected threshold to be 100 but got %v", threshold)
+	}
+
+	discount, err := runtime.Call("discount", 250, []string{"new", "vip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discount != int64(25) {
+		t.Errorf("Expected a discount of 25 but got %v", discount)
+	}
+}
+
+func TestRuntimeRegister(t *testing.T) {
+	runtime := elara.New()
+	err := runtime.Register("lookupPrice", func(item string) (int, error) {
+		if item == "apple" {
+			return 50, nil
+		}
+		return 0, errors.New("unknown item " + item)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runtime.Set("basket", map[string]int{"apple": 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := runtime.Eval(`let missing = lookupPrice("pear")
+[lookupPrice("apple") * basket["apple"], missing.message]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{int64(200), "unknown item pear"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	runtime := elara.New()
+	if _, err := runtime.Eval(`let x = (`); err == nil {
+		t.Error("Expected a syntax error")
+	} else if _, isSyntaxError := err.(*elara.SyntaxError); !isSyntaxError {
+		t.Errorf("Expected a syntax error but got %v", err)
+	}
+
+	_, err := runtime.Eval(`undefinedFunction(3)`)
+	if _, isRuntimeError := err.(*elara.RuntimeError); !isRuntimeError {
+		t.Errorf("Expected a runtime error but got %v", err)
+	}
+
+	_, err = runtime.Eval(`let fails = () => error("rule failed")
+fails()`)
+	if err == nil || !strings.Contains(err.Error(), "rule failed") {
+		t.Errorf("Expected the Elara Error to be returned, but got %v", err)
+	}
+	_, err = runtime.Call("fails")
+	if err == nil || !strings.Contains(err.Error(), "rule failed") {
+		t.Errorf("Expected the Elara Error to be returned, but got %v", err)
+	}
+
+	//Each runtime has its own globals
+	if _, exists, _ := elara.New().Global("fails"); exists {
+		t.Error("Expected globals not to be shared between runtimes")
+	}
+}
+
+func TestRuntimeLazyGlobals(t *testing.T) {
+	runtime := elara.New()
+	_, err := runtime.Eval(`let mut evaluations = 0
+let compute = () => {
+    evaluations = evaluations + 1
+    42
+}
+let doubler = () => {
+    evaluations = evaluations + 1
+    (Int value) => value * 2
+}
+let lazy answer = compute()
+let lazy double = doubler()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, exists, err := runtime.Global("answer")
+	if err != nil || !exists || answer != int64(42) {
+		t.Errorf("Expected answer to be 42 but got %v", answer)
+	}
+	doubled, err := runtime.Call("double", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doubled != int64(8) {
+		t.Errorf("Expected 8 but got %v", doubled)
+	}
+	runtime.Call("double", 5)
+	runtime.Global("answer")
+
+	//Each lazy global is only evaluated once, however many times it is read from Go
+	evaluations, _, _ := runtime.Global("evaluations")
+	if evaluations != int64(2) {
+		t.Errorf("Expected 2 evaluations but got %v", evaluations)
+	}
+}
+
+func TestRuntimeLazyGlobalErrors(t *testing.T) {
+	runtime := elara.New()
+	_, err := runtime.Eval(`let lazy broken = [1][5]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := runtime.Global("broken"); err == nil || !strings.Contains(err.Error(), "Index 5 out of bounds") {
+		t.Errorf("Expected reading broken to fail, but got %v", err)
+	}
+	if _, err := runtime.Call("broken"); err == nil || !strings.Contains(err.Error(), "Index 5 out of bounds") {
+		t.Errorf("Expected calling broken to fail, but got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestSetLiteralsAndMembership(t *testing.T) {
+	code := `let numbers = #{1, 2, 3, 2}
+let added = numbers.add(4).remove(1)
+numbers.size
+numbers.contains(2)
+numbers.contains(4)
+added.contains(4)
+added.contains(1)
+numbers is Set<Int>
+#{1, 2} == #{2, 1}`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(3),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect set output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	code := `let a = #{1, 2, 3}
+let b = #{3, 4}
+a.union(b)
+a.intersect(b)
+a.difference(b)`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expected := []string{"#{1, 2, 3, 4}", "#{3}", "#{1, 2}"}
+
+	for i, result := range results[2:] {
+		if result.String() != expected[i] {
+			t.Errorf("Incorrect set algebra output, got %s but expected %s", result.String(), expected[i])
+		}
+	}
+}
+
+func TestSetConversionsAndStructElements(t *testing.T) {
+	code := `struct Point {
+    Int x
+    Int y
+}
+let points = [Point(1, 2), Point(1, 2), Point(3, 4)].toSet()
+let keys = {"a": 1, "b": 2}.keySet()
+points.size
+points.contains(Point(3, 4))
+keys.contains("b")
+keys.toList()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(2),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-4:len(results)-1], expectedResults) {
+		t.Errorf("Incorrect set output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+	if list := results[len(results)-1].String(); list != "[a, b]" {
+		t.Errorf("Set was not converted to a list in insertion order, got %s", list)
+	}
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"reflect"
+	"testing"
+)
+
+func TestStringRunes(t *testing.T) {
+	code := `let word = "héllo"
+let joined = word + " wörld"
+word.size
+joined.size
+joined.length()
+word[1]
+joined[7]
+joined.substring(6, 11)
+joined.indexOf("wö")
+joined == "héllo wörld"`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(5),
+		interpreter.IntValue(11),
+		interpreter.IntValue(11),
+		interpreter.CharValue('é'),
+		interpreter.CharValue('ö'),
+		interpreter.StringValue("wörld"),
+		interpreter.IntValue(6),
+		interpreter.BooleanValue(true),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect string output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestStringRepetition(t *testing.T) {
+	code := `"ab" * 2
+("ab" * 0).size
+["a", "b"].flatMap((String s) => s + "!")`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("abab"),
+		interpreter.IntValue(0),
+		interpreter.StringValue("a!b!"),
+	}
+
+	if formatValues(results) != formatValues(expectedResults) { //flatMap gives a [Char], rather than a String
+		t.Errorf("Incorrect string output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+//Long strings have their runes indexed, which has to give the same results as counting them
+func TestLongStringIndexing(t *testing.T) {
+	code := `let ascii = "abcd" * 30
+let mixed = "abcé" * 30
+let endsInAccent = "a" * 100 + "é"
+[ascii[119], ascii.size, mixed[7], mixed[119], mixed.size, mixed.substring(2, 6), endsInAccent[100], endsInAccent.substring(99, 101)]`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expected := "[d, 120, é, é, 120, céab, é, aé]"
+	if actual := results[len(results)-1].String(); actual != expected {
+		t.Errorf("Incorrect string output, got %s but expected %s", actual, expected)
+	}
+
+	defer expectPanicContaining(t, "Index 120 out of bounds for string of length 120")
+	base.Execute(nil, `("abcé" * 30)[120]`, false)
+}
+
+func TestStringFunctions(t *testing.T) {
+	code := `"  padded  ".trim()
+"Elara".startsWith("El")
+"Elara".endsWith("ra")
+"Elara".contains("lar")
+"Elara".indexOf("z")
+"a-b-a".replace("a", "c")
+"Elara".toUpper()
+"Elara".toLower()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("padded"),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(true),
+		interpreter.IntValue(-1),
+		interpreter.StringValue("c-b-c"),
+		interpreter.StringValue("ELARA"),
+		interpreter.StringValue("elara"),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect string output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestStringSplitting(t *testing.T) {
+	code := `"a,b,c".split(",")
+"first\nsecond\n".lines()
+"ab".chars()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("a"), interpreter.StringValue("b"), interpreter.StringValue("c")}),
+		interpreter.CollectionValue(interpreter.StringType, []*interpreter.Value{interpreter.StringValue("first"), interpreter.StringValue("second")}),
+		interpreter.CollectionValue(interpreter.CharType, []*interpreter.Value{interpreter.CharValue('a'), interpreter.CharValue('b')}),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect string output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestStringBuilder(t *testing.T) {
+	code := `let builder = stringBuilder()
+for i in 0..10000 {
+    builder.append(i % 10)
+}
+let built = builder.build()
+built.size
+built.substring(0, 12)`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.IntValue(10000),
+		interpreter.StringValue("012345678901"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect string builder output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/ElaraLang/elara/elara"
+	"github.com/ElaraLang/elara/interpreter"
+	"testing"
+	"time"
+)
+
+func runWithClock(t *testing.T, clock interpreter.Clock, code string) string {
+	stdout := &bytes.Buffer{}
+	runtime := elara.New(interpreter.WithStdout(stdout), interpreter.WithClock(clock))
+	if _, err := runtime.Eval(code); err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String()
+}
+
+func TestTimersRunInOrder(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := interpreter.NewVirtualClock(start)
+	output := runWithClock(t, clock, `setTimeout(() => stdout.write("c"), 3000)
+setTimeout(() => stdout.write("a"), 1000)
+setTimeout(() => stdout.write("b"), 1000)
+setTimeout(() => setTimeout(() => stdout.write("d"), 5000), 0)
+stdout.write("start ")`)
+
+	if output != "start abcd" {
+		t.Errorf("Expected timers to run after the script, in order, but got %q", output)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 5*time.Second {
+		t.Errorf("Expected the clock to have moved 5 seconds, but it moved %s", elapsed)
+	}
+}
+
+func TestIntervalsAndCancelling(t *testing.T) {
+	clock := interpreter.NewVirtualClock(time.Unix(0, 0))
+	output := runWithClock(t, clock, `let mut ticks = 0
+let cancelled = setTimeout(() => stdout.write("never"), 500)
+cancelled.cancel()
+let interval = setInterval(() => {
+    ticks = ticks + 1
+    stdout.write(ticks.toString())
+}, 100)
+setTimeout(() => interval.cancel(), 350)`)
+
+	if output != "123" {
+		t.Errorf("Expected the interval to run 3 times, but got %q", output)
+	}
+}
+
+func TestTimersWithRealClock(t *testing.T) {
+	realStart := time.Now()
+	stdout := &bytes.Buffer{}
+	runtime := elara.New(interpreter.WithStdout(stdout))
+	if _, err := runtime.Eval(`setTimeout(() => stdout.write("late"), 50)
+stdout.write("early ")`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "early late" || time.Since(realStart) < 50*time.Millisecond {
+		t.Errorf("Expected the timer to wait, but got %q after %s", stdout.String(), time.Since(realStart))
+	}
+}
+
+func TestVirtualClockOnlyMovesForChosenTimeouts(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := interpreter.NewVirtualClock(start)
+	output := runWithClock(t, clock, `let empty = channel<String>(0)
+let ready = channel<String>(1)
+ready.send("ready ")
+stdout.write(select([onReceive(ready, (Any value) => value), onTimeout(1000, () => "timed out ")]))
+stdout.write(select([onReceive(empty, (Any value) => value), onTimeout(2000, () => "timed out"), onTimeout(3000, () => "never")]))`)
+
+	if output != "ready timed out" {
+		t.Errorf("Expected the channel and then the earlier timeout to be chosen, but got %q", output)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 2*time.Second {
+		t.Errorf("Expected the clock to only move to the chosen timeout, but it moved %s", elapsed)
+	}
+}
+
+func TestOutputIsFlushedEveryTurn(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	runtime := elara.New(interpreter.WithStdout(stdout), interpreter.WithStderr(stderr), interpreter.WithClock(interpreter.NewVirtualClock(time.Unix(0, 0))))
+	if err := runtime.Register("written", func() string { return stdout.String() + "|" + stderr.String() }); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runtime.Eval(`stderr.write("error")
+stdout.write(written() + " ")
+setTimeout(() => stdout.write("first "), 1000)
+setTimeout(() => stdout.write(written()), 2000)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//stderr is seen straight away, and stdout by the end of the timer that wrote it
+	if expected := "|error first |error first |error"; stdout.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, stdout.String())
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"github.com/ElaraLang/elara/base"
+	"github.com/ElaraLang/elara/interpreter"
+	"github.com/ElaraLang/elara/lexer"
+	"github.com/ElaraLang/elara/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTupleDestructuring(t *testing.T) {
+	code := `let t = (1, "a")
+let (x, y) = t
+x
+y
+t is (Int, String)
+t is (String, Int)
+let (q, (w, e)) = (1, (2, 3))
+e
+let (first, _) = [7, 8]
+first`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(1),
+		interpreter.StringValue("a"),
+		interpreter.BooleanValue(true),
+		interpreter.BooleanValue(false),
+		nil,
+		interpreter.IntValue(3),
+		nil,
+		interpreter.IntValue(7),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect tuple output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestStructAndParameterDestructuring(t *testing.T) {
+	code := `struct Point {
+    Int x
+    Int y
+}
+let (px, py) = Point(3, 4)
+px + py
+let sum = ((Int a, Int b)) => a + b
+sum((5, 6))`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		nil,
+		nil,
+		interpreter.IntValue(7),
+		nil,
+		interpreter.IntValue(11),
+	}
+
+	if !reflect.DeepEqual(results, expectedResults) {
+		t.Errorf("Incorrect destructuring output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestDestructuredParameterOverloads(t *testing.T) {
+	code := `struct Point {
+    Int x
+    Int y
+}
+let describe = ((Int a, Int b)) => "ints"
+let describe = ((String a, String b)) => "strings"
+let strings = ("a", "b")
+let point = Point(1, 2)
+strings.describe()
+point.describe()`
+	results, _, _, _ := base.Execute(nil, code, false)
+	expectedResults := []*interpreter.Value{
+		interpreter.StringValue("strings"),
+		interpreter.StringValue("ints"),
+	}
+
+	if !reflect.DeepEqual(results[len(results)-2:], expectedResults) {
+		t.Errorf("Incorrect overload output, got %v but expected %v", formatValues(results), formatValues(expectedResults))
+	}
+}
+
+func TestLazyParametersCannotBeDestructured(t *testing.T) {
+	_, errs := parser.NewParser(lexer.Lex("let first = (lazy (a, b)) => a")).Parse()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "Lazy parameters cannot be destructured") {
+		t.Errorf("Expected a lazy destructured parameter to be rejected, but got %v", errs)
+	}
+}
+
+func TestDestructuringArityMismatch(t *testing.T) {
+	defer expectPanicContaining(t, "into a pattern with 2 elements")
+	base.Execute(nil, "let (a, b) = (1, 2, 3)", false)
+}
+
+func TestTupleIndexOutOfBounds(t *testing.T) {
+	for _, index := range []string{"2", "0 - 1"} {
+		func() {
+			defer expectPanicContaining(t, "out of bounds for tuple of size 2")
+			base.Execute(nil, "(1, 2)["+index+"]", false)
+		}()
+	}
+}